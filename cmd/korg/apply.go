@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ApplyAudit removes the members found inactive by an audit from the orgs they
+// were audited in, along with every team of those orgs, see
+// removeInactiveMembers. All removals are made in a single commit.
+func ApplyAudit(o Options, inactive []UserInfo) error {
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+
+	usernames := []string{}
+	for _, member := range inactive {
+		if !stringInSlice(usernames, member.Username) {
+			usernames = append(usernames, member.Username)
+		}
+	}
+
+	changes := newChangeSet(o.RepoRoot)
+	results := newSummary(usernames)
+	orgs, err := removeInactiveMembers(o, inactive, changes, results)
+	if err != nil {
+		return err
+	}
+
+	results.print("removed")
+
+	if len(changes.modified) == 0 {
+		fmt.Println("nothing to change")
+		return nil
+	}
+
+	if o.Confirm {
+		if err := changes.save(); err != nil {
+			return fmt.Errorf("saving config: %s", err)
+		}
+
+		fmt.Println("committing changes")
+
+		if err := commitChanges(o.RepoRoot, changes.modified, auditCommitMessage(results, inactive, orgs)); err != nil {
+			return fmt.Errorf("committing changes: %s", err)
+		}
+	}
+	return nil
+}
+
+// removeInactiveMembers removes inactive members from the orgs they were
+// audited in and their teams, recording the changes without saving them. Org
+// admins, the approvers and reviewers of the org config who are required to
+// be members, and members who may approve in any OWNERS file are kept. It
+// returns the orgs audited.
+func removeInactiveMembers(o Options, inactive []UserInfo, changes *changeSet, results *summary) ([]string, error) {
+	orgs := []string{}
+	for _, member := range inactive {
+		for _, org := range member.Orgs {
+			if !stringInSlice(orgs, org) {
+				orgs = append(orgs, org)
+			}
+		}
+	}
+	sort.Strings(orgs)
+
+	for _, orgName := range orgs {
+		ownersPath := filepath.Join(filepath.Dir(fmt.Sprintf(orgConfigPathFormat, orgName)), "OWNERS")
+		owners, err := readOwnersFile(filepath.Join(o.RepoRoot, ownersPath))
+		if err != nil {
+			return nil, err
+		}
+
+		toRemove := []string{}
+		for _, member := range inactive {
+			if !stringInSlice(member.Orgs, orgName) {
+				continue
+			}
+
+			if stringInSliceCaseAgnostic(owners.Approvers, member.Username) || stringInSliceCaseAgnostic(owners.Reviewers, member.Username) {
+				results.skip(member.Username, fmt.Sprintf("is listed in %s", ownersPath))
+				continue
+			}
+
+			if reason := approverReason(member); reason != "" {
+				results.skip(member.Username, reason)
+				continue
+			}
+
+			toRemove = append(toRemove, member.Username)
+		}
+
+		if err := removeMembersFromOrg(orgName, toRemove, changes, results); err != nil {
+			return nil, err
+		}
+	}
+
+	return orgs, nil
+}
+
+// approverReason returns why member may be an approver in an OWNERS file,
+// empty if they are known not to be: they are listed as an approver, their
+// role is unknown, e.g. when found through hound, or their OWNERS files
+// couldn't be looked up.
+func approverReason(member UserInfo) string {
+	if member.OwnerLookupError != "" {
+		return fmt.Sprintf("unable to look up their OWNERS files: %s", member.OwnerLookupError)
+	}
+	for _, ownership := range member.Owners {
+		switch ownership.Role {
+		case roleApprover:
+			return fmt.Sprintf("is an approver in %s", ownership)
+		case "":
+			return fmt.Sprintf("may be an approver in %s", ownership)
+		}
+	}
+	return ""
+}
+
+// auditCommitMessage lists the members removed from each org along with the
+// activity bar they missed.
+func auditCommitMessage(results *summary, inactive []UserInfo, orgs []string) string {
+	removed := results.changedUsers()
+	lines := []string{
+		fmt.Sprintf("remove %d inactive members from %s", len(removed), strings.Join(orgs, ", ")),
+		"",
+	}
+	for _, member := range inactive {
+		for _, org := range member.Orgs {
+			if !stringInSlice(results.changes[member.Username], org) {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("- %s: %s: %d contributions over the %q period, threshold %d", org, member.Username, member.Contributions, member.Period, member.ActivityThreshold))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
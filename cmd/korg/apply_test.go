@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestRemoveInactiveMembers(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/kubernetes/org.yaml": `admins:
+- alice
+members:
+- bob
+- carol
+- dave
+- erin
+- frank
+teams:
+  top:
+    members:
+    - carol
+    - dave
+    privacy: closed
+`,
+		"config/kubernetes/OWNERS":        "approvers:\n- bob\n",
+		"config/kubernetes-sigs/org.yaml": "admins:\n- alice\nmembers:\n- dave\n- gina\n",
+	})
+
+	inactive := []UserInfo{
+		{Username: "alice", Orgs: []string{"kubernetes"}},
+		{Username: "bob", Orgs: []string{"kubernetes"}},
+		{Username: "carol", Orgs: []string{"kubernetes"}, IsOwner: true, Owners: []Ownership{
+			{Path: "kubernetes/test/OWNERS", Role: roleReviewer},
+			{Path: "kubernetes/OWNERS", Role: roleApprover},
+		}},
+		{Username: "dave", Orgs: []string{"kubernetes", "kubernetes-sigs"}, IsOwner: true, Owners: []Ownership{
+			{Path: "kubernetes/test/OWNERS", Role: roleReviewer},
+		}},
+		{Username: "erin", Orgs: []string{"kubernetes"}, OwnerLookupError: "giving up after 3 attempts"},
+		{Username: "frank", Orgs: []string{"kubernetes"}, IsOwner: true, Owners: []Ownership{
+			{Path: "kubernetes/kubernetes/OWNERS"},
+		}},
+		{Username: "gina", Orgs: []string{"kubernetes-sigs"}},
+	}
+
+	changes := newChangeSet(root)
+	results := newSummary([]string{"alice", "bob", "carol", "dave", "erin", "frank", "gina"})
+	orgs, err := removeInactiveMembers(Options{RepoRoot: root}, inactive, changes, results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := changes.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"kubernetes", "kubernetes-sigs"}; !reflect.DeepEqual(orgs, expected) {
+		t.Errorf("unexpected orgs %v, expected %v", orgs, expected)
+	}
+
+	expectedFiles := map[string]string{
+		"config/kubernetes/org.yaml": `admins:
+- alice
+members:
+- bob
+- carol
+- erin
+- frank
+teams:
+  top:
+    members:
+    - carol
+    privacy: closed
+`,
+		"config/kubernetes-sigs/org.yaml": "admins:\n- alice\n",
+	}
+	for path, expected := range expectedFiles {
+		got, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != expected {
+			t.Errorf("unexpected contents of %s:\n%s\nexpected:\n%s", path, got, expected)
+		}
+	}
+
+	expectedSkipped := map[string][]string{
+		"alice": {"is an admin for org kubernetes (" + filepath.Join(root, "config/kubernetes/org.yaml") + ":2)"},
+		"bob":   {"is listed in config/kubernetes/OWNERS"},
+		"carol": {"is an approver in kubernetes/OWNERS (approver)"},
+		"erin":  {"unable to look up their OWNERS files: giving up after 3 attempts"},
+		"frank": {"may be an approver in kubernetes/kubernetes/OWNERS"},
+	}
+	if !reflect.DeepEqual(results.skipped, expectedSkipped) {
+		t.Errorf("unexpected skips %v, expected %v", results.skipped, expectedSkipped)
+	}
+	expectedChanges := map[string][]string{
+		"dave": {"kubernetes", "kubernetes/top", "kubernetes-sigs"},
+		"gina": {"kubernetes-sigs"},
+	}
+	if !reflect.DeepEqual(results.changes, expectedChanges) {
+		t.Errorf("unexpected changes %v, expected %v", results.changes, expectedChanges)
+	}
+}
+
+func TestAuditCommitMessage(t *testing.T) {
+	inactive := []UserInfo{
+		{Username: "bob", Orgs: []string{"kubernetes"}, Contributions: 1, ActivityThreshold: 5, Period: "y"},
+		{Username: "dave", Orgs: []string{"kubernetes"}, Contributions: 0, ActivityThreshold: 5, Period: "y"},
+		{Username: "dave", Orgs: []string{"kubernetes-sigs"}, Contributions: 2, ActivityThreshold: 10, Period: "q"},
+	}
+	results := newSummary([]string{"bob", "dave"})
+	results.skip("bob", "is listed in config/kubernetes/OWNERS")
+	results.changed("dave", "kubernetes")
+	results.changed("dave", "kubernetes/top")
+	results.changed("dave", "kubernetes-sigs")
+
+	expected := `remove 1 inactive members from kubernetes, kubernetes-sigs
+
+- kubernetes: dave: 0 contributions over the "y" period, threshold 5
+- kubernetes-sigs: dave: 2 contributions over the "q" period, threshold 10`
+	if got := auditCommitMessage(results, inactive, []string{"kubernetes", "kubernetes-sigs"}); got != expected {
+		t.Errorf("unexpected message:\n%s\nexpected:\n%s", got, expected)
+	}
+}
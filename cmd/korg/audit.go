@@ -18,356 +18,313 @@ package main
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
 )
 
 type Contribution struct {
-	Rank         int
-	Username     string
-	ContribCount int
-	Orgs         []string
-}
-
-type Values struct {
-	Items [][]interface{} `json:"values,omitempty"`
-}
-
-type Frames struct {
-	Schema map[string]interface{} `json:"schema,omitempty"`
-	Data   Values                 `json:"data,omitempty"`
-}
-
-type DevStatsRequest struct {
-	Queries []Query `json:"queries"`
-}
-
-type Query struct {
-	RefID        string `json:"refId"`
-	DatasourceID int    `json:"datasourceId"`
-	RawSQL       string `json:"rawSql"`
-	Format       string `json:"format"`
+	Rank         int      `json:"rank,omitempty"`
+	Username     string   `json:"username"`
+	ContribCount int      `json:"contributions"`
+	Orgs         []string `json:"orgs,omitempty"`
 }
 
 type AuditOptions struct {
 	Period            string
 	ActivityThreshold int
 	OutputFile        string
+	Format            string
 	ExceptionsFile    string
 	CheckOwners       bool
+	OwnersDir         string
+	OwnersConcurrency int
+	OwnersTimeout     time.Duration
+	OwnersRetries     int
+	OwnersQPS         float64
 	CheckTeams        bool
+	Apply             bool
+
+	// where contributions are read from, see newContributionSource
+	ContributionsSource string
+	ContributionsFile   string
+	GitRepos            []string
+	GitEmailsFile       string
+
+	// SnapshotFile is where the classification of every audited member is
+	// saved, to compare audits with korg audit diff
+	SnapshotFile string
+
+	// AliasesFile maps current logins to previous ones, see readAliases
+	AliasesFile string
+
+	// NotifyTemplate is a text/template rendered for each inactive member
+	// into NotifyDir, see WriteNotifications
+	NotifyTemplate     string
+	NotifyDir          string
+	NotifyMode         string
+	NotifyMentionLimit int
 }
 
 type UserInfo struct {
-	Username      string
-	Contributions int
-	Orgs          []string
-	Teams         map[string][]string
-	IsOwner       bool
+	Username      string              `json:"username"`
+	Contributions int                 `json:"contributions"`
+	Orgs          []string            `json:"orgs"`
+	Teams         map[string][]string `json:"teams"`
+	IsOwner       bool                `json:"isOwner"`
+	// ActivityThreshold and Period are the activity bar the member was held
+	// to in the org they were audited in.
+	ActivityThreshold int    `json:"activityThreshold"`
+	Period            string `json:"period"`
+	// Owners lists the OWNERS files the user is listed in, if known.
+	Owners []Ownership `json:"owners"`
+	// OwnerLookupError is set when the OWNERS files of the user couldn't be
+	// looked up, in which case whether the user is an owner is unknown.
+	OwnerLookupError string `json:"ownerLookupError"`
+	// ExceptionReason is set for members kept despite being below the
+	// activity threshold.
+	ExceptionReason string `json:"exceptionReason"`
 }
 
-type Exception struct {
-	Username string
-	Reason   string
-}
-
-func GetAllUsersInOrgs(o Options, orgs []string) (map[string]UserInfo, error) {
+// GetAllUsersInOrg returns the members and admins of an org, keyed by their
+// normalized username, along with the top-level teams of the org they are on.
+func GetAllUsersInOrg(config org.Config, orgName string) map[string]UserInfo {
 	users := make(map[string]UserInfo)
-	config, err := LoadOrgs(o)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, org := range orgs {
-		for _, u := range append(config[org].Members, config[org].Admins...) {
-			if user, found := users[strings.ToLower(u)]; found {
-				if user.Orgs == nil {
-					user.Orgs = []string{}
-				}
-				user.Orgs = append(user.Orgs, org)
-				users[strings.ToLower(u)] = user
-			} else {
-				users[strings.ToLower(u)] = UserInfo{
-					Username: u,
-					Orgs:     []string{org},
-				}
+	for _, u := range append(config.Members, config.Admins...) {
+		if _, found := users[github.NormLogin(u)]; !found {
+			users[github.NormLogin(u)] = UserInfo{
+				Username: u,
+				Orgs:     []string{orgName},
+				Teams:    map[string][]string{},
 			}
 		}
+	}
 
-		for teamName, team := range config[org].Teams {
-			for _, u := range append(team.Members, team.Maintainers...) {
-				if user, found := users[strings.ToLower(u)]; found {
-					if user.Teams == nil {
-						user.Teams = make(map[string][]string)
-					}
-					user.Teams[org] = append(user.Teams[org], teamName)
-					users[strings.ToLower(u)] = user
-				} else {
-					users[strings.ToLower(u)] = UserInfo{
-						Username: u,
-						Teams:    map[string][]string{org: {teamName}},
-					}
-				}
+	for teamName, team := range config.Teams {
+		for _, u := range append(team.Members, team.Maintainers...) {
+			user, found := users[github.NormLogin(u)]
+			if !found || stringInSlice(user.Teams[orgName], teamName) {
+				continue
 			}
+			user.Teams[orgName] = append(user.Teams[orgName], teamName)
 		}
 	}
 
-	return users, nil
+	return users
 }
 
-func GetContributions(period string) (map[string]Contribution, error) {
-	postBody := DevStatsRequest{
-		Queries: []Query{
-			{
-				RefID:        "A",
-				DatasourceID: 1,
-				RawSQL: fmt.Sprintf(`select
-  sub."Rank",
-  sub.name as name,
-  sub.value
-from (
-  select row_number() over (order by sum(value) desc) as "Rank",
-    split_part(name, '$$$', 1) as name,
-    sum(value) as value
-  from
-    shdev
-  where
-    series = 'hdev_contributionsallall'
-    and period = '%s'
-  group by
-    split_part(name, '$$$', 1)
-) sub`, period),
-				Format: "table",
-			},
-		},
-	}
-
-	requestBody, err := json.Marshal(postBody)
-	if err != nil {
-		return nil, err
-	}
+// contributionCount returns the contributions of username, 0 if unknown.
+// contribs are expected to be normalized by ids.
+func contributionCount(contribs map[string]Contribution, ids *identities, username string) int {
+	return contribs[ids.resolve(username)].ContribCount
+}
 
-	resp, err := http.Post("https://k8s.devstats.cncf.io/api/ds/query", "application/json", bytes.NewBuffer(requestBody))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func OrgAudit(o Options) error {
+	var exceptions *Exceptions
+	if o.ExceptionsFile != "" {
+		fmt.Printf("reading exceptions from %s\n", o.ExceptionsFile)
+		var err error
+		exceptions, err = ReadExceptions(o.ExceptionsFile)
+		if err != nil {
+			return err
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad error code from devstats: %d: %w", resp.StatusCode, err)
-	}
+		// Print exceptions to stdout
+		now := time.Now()
+		fmt.Println("Total Exceptions:", len(exceptions.list))
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Username", "Reason", "Approver", "Expires", "Orgs"})
+		for _, exception := range exceptions.list {
+			expires := exception.Expires
+			if exception.expired(now) {
+				expires += " (expired)"
+			}
+			table.Append([]string{exception.Username, exception.Reason, exception.Approver, expires, strings.Join(exception.Orgs, ", ")})
+		}
+		table.Render()
 
-	var parsed map[string]map[string]map[string][]Frames
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal(body, &parsed)
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse json from devstats: %w", err)
+		for _, warning := range exceptions.warnings(now) {
+			fmt.Println("WARNING:", warning)
+		}
 	}
 
-	ranks := parsed["results"]["A"]["frames"][0].Data.Items[0]
-	usernames := parsed["results"]["A"]["frames"][0].Data.Items[1]
-	contribCounts := parsed["results"]["A"]["frames"][0].Data.Items[2]
-
-	contribs := make(map[string]Contribution)
-	for i := 0; i < len(ranks); i++ {
-		username := usernames[i].(string)
-		contribs[username] = Contribution{
-			int(ranks[i].(float64)),
-			username,
-			int(contribCounts[i].(float64)),
-			[]string{},
+	// audit every org unless some were selected
+	if len(o.Orgs) == 0 {
+		orgs, err := discoverOrgs(o.RepoRoot)
+		if err != nil {
+			return err
 		}
+		o.Orgs = orgs
 	}
-	return contribs, nil
-}
-
-func ReadExceptions(filepath string) ([]Exception, error) {
-	var exceptions []Exception
 
-	data, err := os.ReadFile(filepath)
+	policy, err := readPolicy(o)
 	if err != nil {
-		return exceptions, err
+		return err
 	}
 
-	r := csv.NewReader(bytes.NewBuffer(data))
-	records, err := r.ReadAll()
+	source, err := newContributionSource(o)
 	if err != nil {
-		return exceptions, err
-	}
-
-	for _, record := range records[1:] {
-		exceptions = append(exceptions, Exception{Username: record[0], Reason: record[1]})
-	}
-
-	return exceptions, nil
-}
-
-func usernameNotInContributors(contribs map[string]Contribution, username string) bool {
-	_, found := contribs[username]
-
-	return !found
-}
-
-func usernameBelowActivityThreshold(contribs map[string]Contribution, username string, activityThreshold int) bool {
-	if !usernameNotInContributors(contribs, username) {
-		return false
-	}
-
-	if contribs[strings.ToLower(username)].ContribCount <= activityThreshold {
-		return true
-	}
-
-	return false
-}
-
-func usernameInExceptions(exceptionalUsers []string, username string) bool {
-	for _, exceptionalUser := range exceptionalUsers {
-		if exceptionalUser == username {
-			return true
-		}
+		return err
 	}
 
-	return false
-}
-
-func OrgAudit(o Options) error {
-	fmt.Printf("Running analysis with a lookback period of %s and activity threshold of %d\n", o.Period, o.ActivityThreshold)
-
-	var exceptionalUsers []string
-	if o.ExceptionsFile != "" {
-		fmt.Printf("reading exceptions from %s\n", o.ExceptionsFile)
-		exceptions, err := ReadExceptions(o.ExceptionsFile)
+	var ids *identities
+	if o.AliasesFile != "" {
+		ids, err = readAliases(o.AliasesFile)
 		if err != nil {
 			return err
 		}
+		fmt.Printf("matching renamed accounts: %s\n", ids)
+	}
 
-		// build indexable map for exceptions
-		for _, exception := range exceptions {
-			exceptionalUsers = append(exceptionalUsers, exception.Username)
+	// contributions are fetched once per period used by the policy
+	contributionsByPeriod := map[string]map[string]Contribution{}
+	getContributions := func(period string) (map[string]Contribution, error) {
+		if contributions, found := contributionsByPeriod[period]; found {
+			return contributions, nil
 		}
 
-		// Print exceptions to stdout
-		fmt.Println("Total Exceptions:", len(exceptions))
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Username", "Reason"})
-		for _, exception := range exceptions {
-			table.Append([]string{exception.Username, exception.Reason})
+		fmt.Printf("fetching contributions over the %q period from %s\n", period, source)
+		contributions, err := source.GetContributions(period)
+		if err != nil {
+			return nil, err
 		}
-		table.Render()
-	}
 
-	fmt.Println("fetching data from devstats")
-	contributions, err := GetContributions(o.Period)
-	if err != nil {
-		return err
+		fmt.Println("total contributors:", len(contributions))
+		contributions = ids.normalizeContributions(contributions)
+		contributionsByPeriod[period] = contributions
+		return contributions, nil
 	}
 
-	fmt.Println("total contributors:", len(contributions))
-
 	fmt.Println("fetching org members")
-	users, err := GetAllUsersInOrgs(o, validOrgs)
+	config, err := LoadOrgs(o)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("filtering org members")
 	var orgMembersBelowThresholdAfterException []UserInfo
-	for _, userInfo := range users {
-		if usernameInExceptions(exceptionalUsers, userInfo.Username) {
-			fmt.Printf("username %s in exceptions. skipping...\n", userInfo.Username)
-			continue
-		}
-		if usernameNotInContributors(contributions, userInfo.Username) ||
-			usernameBelowActivityThreshold(contributions, userInfo.Username, o.ActivityThreshold) {
+	var orgMembersInExceptions []UserInfo
+	totals := map[string]int{}
+	snapshot := newSnapshot(time.Now(), o.Orgs)
+	for _, orgName := range o.Orgs {
+		users := GetAllUsersInOrg(config[orgName], orgName)
+		fmt.Printf("auditing %d members of %s, who need %s unless a team rule applies\n", len(users), orgName, policy.auditBars(o, orgName, nil)[0])
+
+		for _, userInfo := range users {
+			// members are held to the most lenient bar applying to them
+			bars := policy.auditBars(o, orgName, userInfo.Teams[orgName])
+			active := false
+			for _, bar := range bars {
+				contributions, err := getContributions(bar.period)
+				if err != nil {
+					return err
+				}
+				if contributionCount(contributions, ids, userInfo.Username) > bar.threshold {
+					active = true
+					break
+				}
+			}
+
+			// members missing every bar are reported against the first one
+			contributions, err := getContributions(bars[0].period)
+			if err != nil {
+				return err
+			}
+			userInfo.Contributions = contributionCount(contributions, ids, userInfo.Username)
+			userInfo.ActivityThreshold = bars[0].threshold
+			userInfo.Period = bars[0].period
+
+			if active {
+				snapshot.add(userInfo, orgName, statusActive)
+				continue
+			}
+
+			if exception := exceptions.lookup(userInfo.Username, orgName); exception != nil {
+				if !exception.expired(time.Now()) {
+					fmt.Printf("username %s in exceptions. skipping...\n", userInfo.Username)
+					userInfo.ExceptionReason = exception.Reason
+					orgMembersInExceptions = append(orgMembersInExceptions, userInfo)
+					snapshot.add(userInfo, orgName, statusExcepted)
+					continue
+				}
+				fmt.Printf("exception for %s expired on %s, auditing as usual\n", userInfo.Username, exception.Expires)
+			}
 
-			userInfo.Contributions = contributions[userInfo.Username].ContribCount
 			orgMembersBelowThresholdAfterException = append(orgMembersBelowThresholdAfterException, userInfo)
+			snapshot.add(userInfo, orgName, statusInactive)
+			totals[orgName]++
 
-			fmt.Println("user below threshold or not in devstats:", userInfo.Username, " contributions: ", contributions[userInfo.Username].ContribCount)
+			fmt.Printf("user below threshold in %s: %s contributions: %d\n", orgName, userInfo.Username, userInfo.Contributions)
 		}
 	}
 
 	// sort users for readability
 	sort.Slice(orgMembersBelowThresholdAfterException, func(i, j int) bool {
-		return orgMembersBelowThresholdAfterException[i].Username < orgMembersBelowThresholdAfterException[j].Username
+		a, b := orgMembersBelowThresholdAfterException[i], orgMembersBelowThresholdAfterException[j]
+		if a.Orgs[0] != b.Orgs[0] {
+			return a.Orgs[0] < b.Orgs[0]
+		}
+		return a.Username < b.Username
 	})
 
 	// populate if member is an owner
 	if o.CheckOwners {
-		for i, member := range orgMembersBelowThresholdAfterException {
-			isOwner, err := IsOwner(member.Username)
-			if err != nil {
-				return err
-			}
-			fmt.Printf("checking if user %s is owner: %v\n", member.Username, isOwner)
-			member.IsOwner = isOwner
-			orgMembersBelowThresholdAfterException[i] = member
+		owners, err := newOwnersBackend(o)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("looking up owners in %s\n", owners)
+		if failed := lookupOwners(owners, orgMembersBelowThresholdAfterException, newOwnersLookup(o)); failed > 0 {
+			fmt.Printf("owner lookups failed for %d users, reported as unknown\n", failed)
 		}
 	}
 
-	fmt.Println("Total \"Org Members\":", len(users))
-	fmt.Println("Total \"Org Members\" below threshold after exceptions:", len(orgMembersBelowThresholdAfterException))
+	for _, orgName := range o.Orgs {
+		fmt.Printf("Total \"Org Members\" of %s below threshold after exceptions: %d\n", orgName, totals[orgName])
+	}
 
 	f, err := os.Create(o.OutputFile)
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 
-	w := bufio.NewWriter(f)
-	table := tablewriter.NewWriter(w)
-	table.SetAutoWrapText(false)
+	// members in exceptions are reported along with their reason
+	report := append(orgMembersBelowThresholdAfterException, orgMembersInExceptions...)
 
-	headers := []string{"Username", "Orgs"}
-	if o.CheckTeams {
-		headers = append(headers, "Teams")
-	}
-	if o.CheckOwners {
-		headers = append(headers, "Owner", "Owners Link")
+	w := bufio.NewWriter(f)
+	if err := writeReport(w, o, report); err != nil {
+		return err
 	}
-	table.SetHeader(headers)
 
-	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
-	table.SetCenterSeparator("|")
-
-	for _, v := range orgMembersBelowThresholdAfterException {
-		row := []string{
-			v.Username,
-			strings.Join(v.Orgs, ", "),
-		}
+	if err := w.Flush(); err != nil {
+		return err
+	}
 
-		if o.CheckTeams {
-			teams := []string{}
-			for org, team := range v.Teams {
-				teams = append(teams, fmt.Sprintf("%s: %s", org, strings.Join(team, ", ")))
-			}
-			row = append(row, strings.Join(teams, "; "))
+	if o.SnapshotFile != "" {
+		fmt.Printf("writing snapshot to %s\n", o.SnapshotFile)
+		if err := snapshot.write(o.SnapshotFile); err != nil {
+			return err
 		}
+	}
 
-		if o.CheckOwners {
-			if v.IsOwner {
-				row = append(row, "yes", fmt.Sprintf("https://go.k8s.io/owners/%s", v.Username))
-			} else {
-				row = append(row, "no", "")
-			}
+	if o.NotifyTemplate != "" {
+		files, err := WriteNotifications(o, orgMembersBelowThresholdAfterException)
+		if err != nil {
+			return err
 		}
-
-		table.Append(row)
+		fmt.Printf("wrote %d notification(s) to %s\n", len(files), o.NotifyDir)
 	}
-	table.Render()
 
-	w.Flush()
+	if o.Apply {
+		return ApplyAudit(o, orgMembersBelowThresholdAfterException)
+	}
 
 	return nil
 }
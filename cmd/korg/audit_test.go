@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestOrgAudit(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/policy.yaml": `orgs:
+  etcd-io:
+    audit:
+      activityThreshold: 0
+      teams:
+        maintainers:
+          activityThreshold: 20
+  kubernetes:
+    audit:
+      teams:
+        release:
+          activityThreshold: 2
+`,
+		"config/etcd-io/org.yaml": `name: etcd
+admins:
+- alice
+members:
+- bob
+- carol
+teams:
+  maintainers:
+    members:
+    - bob
+    privacy: closed
+`,
+		"config/kubernetes/org.yaml": `name: Kubernetes
+admins:
+- alice
+members:
+- bob
+- carol
+- dave
+`,
+		"config/kubernetes/sig-release/teams.yaml": `teams:
+  release:
+    members:
+    - Dave
+    privacy: closed
+`,
+		"config/other/org.yaml": "name: Other\nmembers:\n- erin\n",
+		"exceptions.csv": `username,reason,expires,orgs
+carol,on leave,,kubernetes
+bob,on leave,2000-01-01,
+`,
+		"contributions.csv": `username,contributions
+alice,50
+bob,1
+carol,5
+dave,3
+`,
+	})
+
+	o := Options{
+		RepoRoot: root,
+		Orgs:     []string{"etcd-io", "kubernetes"},
+		AuditOptions: AuditOptions{
+			Period:              "y",
+			ActivityThreshold:   10,
+			OutputFile:          filepath.Join(root, "audit.json"),
+			Format:              formatJSON,
+			ContributionsSource: sourceFile,
+			ContributionsFile:   filepath.Join(root, "contributions.csv"),
+			ExceptionsFile:      filepath.Join(root, "exceptions.csv"),
+			SnapshotFile:        filepath.Join(root, "snapshot.json"),
+		},
+	}
+	if err := OrgAudit(o); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	contents, err := os.ReadFile(o.OutputFile)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	var report []UserInfo
+	if err := json.Unmarshal(contents, &report); err != nil {
+		t.Fatalf("parsing report: %v", err)
+	}
+
+	got := [][]string{}
+	for _, m := range report {
+		got = append(got, append([]string{m.Username, m.ExceptionReason}, m.Orgs...))
+	}
+	// bob is a maintainer in etcd-io and held to their stricter bar, their
+	// exception expired, dave is on the release team in kubernetes and held
+	// to its more lenient bar, and erin is not in a selected org
+	expected := [][]string{
+		{"bob", "", "etcd-io"},
+		{"bob", "", "kubernetes"},
+		{"carol", "on leave", "kubernetes"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected members in report: %v, expected %v", got, expected)
+	}
+
+	for _, m := range report {
+		threshold := 10
+		if m.Orgs[0] == "etcd-io" {
+			threshold = 20
+		}
+		if m.ActivityThreshold != threshold || m.Period != "y" {
+			t.Errorf("unexpected bar for %s in %s: %d over %q", m.Username, m.Orgs[0], m.ActivityThreshold, m.Period)
+		}
+	}
+
+	snapshot, err := readSnapshot(o.SnapshotFile)
+	if err != nil {
+		t.Fatalf("reading snapshot: %v", err)
+	}
+	statuses := []string{}
+	for _, m := range snapshot.Members {
+		statuses = append(statuses, m.Org+"/"+m.Username+": "+m.Status)
+	}
+	expectedStatuses := []string{
+		"etcd-io/alice: active",
+		"etcd-io/bob: inactive",
+		"etcd-io/carol: active",
+		"kubernetes/alice: active",
+		"kubernetes/bob: inactive",
+		"kubernetes/carol: excepted",
+		"kubernetes/dave: active",
+	}
+	if !reflect.DeepEqual(statuses, expectedStatuses) {
+		t.Errorf("unexpected snapshot: %v, expected %v", statuses, expectedStatuses)
+	}
+}
@@ -0,0 +1,475 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	sourceDevStats = "devstats"
+	sourceFile     = "file"
+	sourceGit      = "git"
+
+	defaultDevStatsURL = "https://k8s.devstats.cncf.io/api/ds/query"
+)
+
+var contributionSources = []string{sourceDevStats, sourceFile, sourceGit}
+
+// ContributionSource provides the contributions of everyone who contributed
+// within a period, keyed by username. Periods follow the devstats naming, see
+// https://github.com/cncf/devstats/blob/master/docs/periods.md.
+type ContributionSource interface {
+	GetContributions(period string) (map[string]Contribution, error)
+	String() string
+}
+
+// newContributionSource returns the source of contributions configured by o.
+func newContributionSource(o Options) (ContributionSource, error) {
+	switch o.ContributionsSource {
+	case sourceDevStats, "":
+		return newDevStatsSource(), nil
+	case sourceFile:
+		return &fileSource{path: o.ContributionsFile}, nil
+	case sourceGit:
+		s := &gitSource{repos: o.GitRepos, now: time.Now}
+		if o.GitEmailsFile != "" {
+			emails, err := readGitEmails(o.GitEmailsFile)
+			if err != nil {
+				return nil, err
+			}
+			s.emails = emails
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown contributions source %q", o.ContributionsSource)
+	}
+}
+
+// validateContributionSource makes sure the flags needed by the configured
+// source are set.
+func validateContributionSource(o Options) error {
+	switch o.ContributionsSource {
+	case sourceDevStats:
+	case sourceFile:
+		if o.ContributionsFile == "" {
+			return fmt.Errorf("please specify a contributions file when using the %s source", sourceFile)
+		}
+	case sourceGit:
+		if len(o.GitRepos) == 0 {
+			return fmt.Errorf("please specify git repos when using the %s source", sourceGit)
+		}
+	default:
+		return fmt.Errorf("unknown contributions source %s, must be one of: %s", o.ContributionsSource, strings.Join(contributionSources, ", "))
+	}
+
+	return nil
+}
+
+// rankContributions ranks contributors by their number of contributions, the
+// way devstats does.
+func rankContributions(contribs map[string]Contribution) {
+	usernames := make([]string, 0, len(contribs))
+	for username := range contribs {
+		usernames = append(usernames, username)
+	}
+	sort.Slice(usernames, func(i, j int) bool {
+		ci, cj := contribs[usernames[i]].ContribCount, contribs[usernames[j]].ContribCount
+		if ci != cj {
+			return ci > cj
+		}
+		return usernames[i] < usernames[j]
+	})
+
+	for i, username := range usernames {
+		c := contribs[username]
+		c.Rank = i + 1
+		contribs[username] = c
+	}
+}
+
+type Values struct {
+	Items [][]interface{} `json:"values,omitempty"`
+}
+
+type Frames struct {
+	Schema map[string]interface{} `json:"schema,omitempty"`
+	Data   Values                 `json:"data,omitempty"`
+}
+
+type Result struct {
+	Frames []Frames `json:"frames,omitempty"`
+}
+
+type DevStatsResponse struct {
+	Results map[string]Result `json:"results"`
+}
+
+type DevStatsRequest struct {
+	Queries []Query `json:"queries"`
+}
+
+type Query struct {
+	RefID        string `json:"refId"`
+	DatasourceID int    `json:"datasourceId"`
+	RawSQL       string `json:"rawSql"`
+	Format       string `json:"format"`
+}
+
+// devStatsSource queries the contributions from devstats.
+type devStatsSource struct {
+	client *http.Client
+	url    string
+}
+
+func newDevStatsSource() *devStatsSource {
+	return &devStatsSource{
+		client: &http.Client{Timeout: 2 * time.Minute},
+		url:    defaultDevStatsURL,
+	}
+}
+
+func (s *devStatsSource) String() string {
+	return s.url
+}
+
+func (s *devStatsSource) GetContributions(period string) (map[string]Contribution, error) {
+	postBody := DevStatsRequest{
+		Queries: []Query{
+			{
+				RefID:        "A",
+				DatasourceID: 1,
+				RawSQL: fmt.Sprintf(`select
+  sub."Rank",
+  sub.name as name,
+  sub.value
+from (
+  select row_number() over (order by sum(value) desc) as "Rank",
+    split_part(name, '$$$', 1) as name,
+    sum(value) as value
+  from
+    shdev
+  where
+    series = 'hdev_contributionsallall'
+    and period = '%s'
+  group by
+    split_part(name, '$$$', 1)
+) sub`, period),
+				Format: "table",
+			},
+		},
+	}
+
+	requestBody, err := json.Marshal(postBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status code from devstats: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDevStatsResponse(body)
+}
+
+// parseDevStatsResponse reads the contributions out of the table returned by
+// devstats for the query of GetContributions.
+func parseDevStatsResponse(body []byte) (map[string]Contribution, error) {
+	var parsed DevStatsResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("unable to parse json from devstats: %w", err)
+	}
+
+	frames := parsed.Results["A"].Frames
+	if len(frames) == 0 || len(frames[0].Data.Items) != 3 {
+		return nil, fmt.Errorf("unexpected response from devstats: expected a table of rank, name and value")
+	}
+
+	ranks := frames[0].Data.Items[0]
+	usernames := frames[0].Data.Items[1]
+	contribCounts := frames[0].Data.Items[2]
+	if len(usernames) != len(ranks) || len(contribCounts) != len(ranks) {
+		return nil, fmt.Errorf("unexpected response from devstats: columns have different lengths")
+	}
+
+	contribs := make(map[string]Contribution)
+	for i := 0; i < len(ranks); i++ {
+		username, ok1 := usernames[i].(string)
+		rank, ok2 := ranks[i].(float64)
+		count, ok3 := contribCounts[i].(float64)
+		if !ok1 || !ok2 || !ok3 {
+			return nil, fmt.Errorf("unexpected response from devstats: bad row %d: %v, %v, %v", i, ranks[i], usernames[i], contribCounts[i])
+		}
+
+		contribs[username] = Contribution{
+			Rank:         int(rank),
+			Username:     username,
+			ContribCount: int(count),
+			Orgs:         []string{},
+		}
+	}
+	return contribs, nil
+}
+
+// fileSource reads contributions from a local file, e.g. an export of
+// devstats or a test fixture. JSON files hold a list of Contribution, CSV
+// files have a header with at least the username and contributions columns.
+// The file is expected to cover the audited period already.
+type fileSource struct {
+	path string
+}
+
+func (s *fileSource) String() string {
+	return s.path
+}
+
+func (s *fileSource) GetContributions(_ string) (map[string]Contribution, error) {
+	contents, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", s.path, err)
+	}
+
+	var list []Contribution
+	switch ext := strings.ToLower(filepath.Ext(s.path)); ext {
+	case ".json":
+		if err := json.Unmarshal(contents, &list); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal contributions from %s: %s", s.path, err)
+		}
+	case ".csv":
+		list, err = readContributionsCSV(contents)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read contributions from %s: %s", s.path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown contributions file type %q, must be .json or .csv", ext)
+	}
+
+	contribs := make(map[string]Contribution)
+	ranked := true
+	for _, c := range list {
+		if c.Username == "" {
+			return nil, fmt.Errorf("contribution without a username in %s", s.path)
+		}
+		if _, found := contribs[c.Username]; found {
+			return nil, fmt.Errorf("%s has more than one entry in %s", c.Username, s.path)
+		}
+		if c.Rank == 0 {
+			ranked = false
+		}
+		contribs[c.Username] = c
+	}
+
+	if !ranked {
+		rankContributions(contribs)
+	}
+
+	return contribs, nil
+}
+
+func readContributionsCSV(contents []byte) ([]Contribution, error) {
+	records, err := csv.NewReader(bytes.NewReader(contents)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("missing header")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"username", "contributions"} {
+		if _, found := columns[required]; !found {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	var list []Contribution
+	for i, record := range records[1:] {
+		count, err := strconv.Atoi(strings.TrimSpace(record[columns["contributions"]]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad contributions: %s", i+2, err)
+		}
+
+		c := Contribution{
+			Username:     strings.TrimSpace(record[columns["username"]]),
+			ContribCount: count,
+		}
+		if col, found := columns["rank"]; found {
+			if c.Rank, err = strconv.Atoi(strings.TrimSpace(record[col])); err != nil {
+				return nil, fmt.Errorf("line %d: bad rank: %s", i+2, err)
+			}
+		}
+		list = append(list, c)
+	}
+
+	return list, nil
+}
+
+// noreplyEmailRe matches the emails GitHub uses for commits of users hiding
+// their email, e.g. 12345+login@users.noreply.github.com.
+var noreplyEmailRe = regexp.MustCompile(`^(?:\d+\+)?([a-zA-Z0-9-]+)@users\.noreply\.github\.com$`)
+
+// periodRe matches the devstats periods gitSource knows how to look back, e.g.
+// "m" for a month or "y10" for ten years.
+var periodRe = regexp.MustCompile(`^([dwmqy])(\d*)$`)
+
+// gitSource counts the commits authored in local clones of repos. Authors are
+// mapped to GitHub logins through their noreply email or the emails map;
+// commits of authors that can't be mapped are not counted.
+type gitSource struct {
+	repos []string
+	// emails maps author emails to GitHub logins
+	emails map[string]string
+	now    func() time.Time
+}
+
+func (s *gitSource) String() string {
+	return fmt.Sprintf("git repos %s", strings.Join(s.repos, ", "))
+}
+
+// readGitEmails reads a JSON object mapping author emails to GitHub logins.
+func readGitEmails(path string) (map[string]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	emails := map[string]string{}
+	if err := json.Unmarshal(contents, &emails); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal emails from %s: %s", path, err)
+	}
+
+	normalized := make(map[string]string, len(emails))
+	for email, login := range emails {
+		normalized[strings.ToLower(email)] = login
+	}
+	return normalized, nil
+}
+
+// periodStart returns when period started, counting back from now.
+func periodStart(now time.Time, period string) (time.Time, error) {
+	m := periodRe.FindStringSubmatch(period)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("unsupported period %q for git repos, must be one of d, w, m, q or y, optionally followed by a count", period)
+	}
+
+	n := 1
+	if m[2] != "" {
+		var err error
+		if n, err = strconv.Atoi(m[2]); err != nil || n < 1 {
+			return time.Time{}, fmt.Errorf("bad count in period %q", period)
+		}
+	}
+
+	switch m[1] {
+	case "d":
+		return now.AddDate(0, 0, -n), nil
+	case "w":
+		return now.AddDate(0, 0, -7*n), nil
+	case "m":
+		return now.AddDate(0, -n, 0), nil
+	case "q":
+		return now.AddDate(0, -3*n, 0), nil
+	default:
+		return now.AddDate(-n, 0, 0), nil
+	}
+}
+
+func (s *gitSource) login(email string) string {
+	email = strings.ToLower(email)
+	if login, found := s.emails[email]; found {
+		return login
+	}
+	if m := noreplyEmailRe.FindStringSubmatch(email); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func (s *gitSource) GetContributions(period string) (map[string]Contribution, error) {
+	since, err := periodStart(s.now(), period)
+	if err != nil {
+		return nil, err
+	}
+
+	contribs := make(map[string]Contribution)
+	unknown := map[string]bool{}
+	for _, repo := range s.repos {
+		r, err := git.PlainOpen(repo)
+		if err != nil {
+			return nil, fmt.Errorf("opening git repo %s: %s", repo, err)
+		}
+
+		commits, err := r.Log(&git.LogOptions{Since: &since})
+		if err != nil {
+			return nil, fmt.Errorf("reading log of %s: %s", repo, err)
+		}
+
+		err = commits.ForEach(func(c *object.Commit) error {
+			login := s.login(c.Author.Email)
+			if login == "" {
+				unknown[c.Author.Email] = true
+				return nil
+			}
+
+			contrib := contribs[login]
+			contrib.Username = login
+			contrib.ContribCount++
+			contribs[login] = contrib
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading log of %s: %s", repo, err)
+		}
+	}
+
+	if len(unknown) > 0 {
+		fmt.Printf("ignoring commits of %d authors with no known GitHub login\n", len(unknown))
+	}
+
+	rankContributions(contribs)
+	return contribs, nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestDevStatsSource(t *testing.T) {
+	response, err := os.ReadFile("testdata/devstats-response.json")
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+
+	var request DevStatsRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	s := newDevStatsSource()
+	s.url = server.URL
+	got, err := s.GetContributions("q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(request.Queries) != 1 || !strings.Contains(request.Queries[0].RawSQL, "period = 'q'") {
+		t.Errorf("expected the query to be for period q, got %+v", request.Queries)
+	}
+
+	expected := map[string]Contribution{
+		"k8s-ci-robot":  {Rank: 1, Username: "k8s-ci-robot", ContribCount: 154321, Orgs: []string{}},
+		"dims":          {Rank: 2, Username: "dims", ContribCount: 10523, Orgs: []string{}},
+		"liggitt":       {Rank: 3, Username: "liggitt", ContribCount: 8734, Orgs: []string{}},
+		"inactive-user": {Rank: 4, Username: "inactive-user", ContribCount: 1, Orgs: []string{}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected contributions:\n%+v\nexpected:\n%+v", got, expected)
+	}
+}
+
+func TestParseDevStatsResponseErrors(t *testing.T) {
+	cases := []struct {
+		desc string
+		body string
+	}{
+		{desc: "invalid json", body: `{`},
+		{desc: "no frames", body: `{"results": {"A": {"frames": []}}}`},
+		{desc: "missing columns", body: `{"results": {"A": {"frames": [{"data": {"values": [[1], ["dims"]]}}]}}}`},
+		{desc: "uneven columns", body: `{"results": {"A": {"frames": [{"data": {"values": [[1, 2], ["dims"], [3]]}}]}}}`},
+		{desc: "bad row", body: `{"results": {"A": {"frames": [{"data": {"values": [[1], [null], [3]]}}]}}}`},
+	}
+
+	for _, c := range cases {
+		if _, err := parseDevStatsResponse([]byte(c.body)); err == nil {
+			t.Errorf("expected an error parsing a response with %s", c.desc)
+		}
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "contributions.json")
+	if err := os.WriteFile(jsonPath, []byte(`[{"rank": 1, "username": "dims", "contributions": 10523}, {"rank": 2, "username": "inactive-user", "contributions": 1}]`), 0644); err != nil {
+		t.Fatalf("writing contributions: %v", err)
+	}
+
+	cases := []struct {
+		path     string
+		expected map[string]Contribution
+	}{
+		{
+			path: "testdata/contributions.csv",
+			expected: map[string]Contribution{
+				"dims":          {Rank: 1, Username: "dims", ContribCount: 10523},
+				"liggitt":       {Rank: 2, Username: "liggitt", ContribCount: 8734},
+				"inactive-user": {Rank: 3, Username: "inactive-user", ContribCount: 1},
+			},
+		},
+		{
+			path: jsonPath,
+			expected: map[string]Contribution{
+				"dims":          {Rank: 1, Username: "dims", ContribCount: 10523},
+				"inactive-user": {Rank: 2, Username: "inactive-user", ContribCount: 1},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		s := &fileSource{path: c.path}
+		got, err := s.GetContributions("y")
+		if err != nil {
+			t.Errorf("unexpected error reading %s: %v", c.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("unexpected contributions from %s:\n%+v\nexpected:\n%+v", c.path, got, c.expected)
+		}
+	}
+}
+
+func TestGitSource(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("initializing repo: %v", err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatalf("getting worktree: %v", err)
+	}
+
+	commits := []struct {
+		email string
+		when  time.Time
+	}{
+		{email: "12345+dims@users.noreply.github.com", when: now.AddDate(0, -2, 0)},
+		{email: "dims@users.noreply.github.com", when: now.AddDate(0, 0, -1)},
+		{email: "Jordan@Example.com", when: now.AddDate(0, 0, -3)},
+		{email: "someone@example.com", when: now.AddDate(0, 0, -3)},
+		{email: "12345+dims@users.noreply.github.com", when: now.AddDate(-2, 0, 0)},
+	}
+	for _, c := range commits {
+		_, err := w.Commit("commit", &git.CommitOptions{
+			AllowEmptyCommits: true,
+			Author:            &object.Signature{Name: c.email, Email: c.email, When: c.when},
+		})
+		if err != nil {
+			t.Fatalf("committing: %v", err)
+		}
+	}
+
+	s := &gitSource{
+		repos:  []string{dir},
+		emails: map[string]string{"jordan@example.com": "liggitt"},
+		now:    func() time.Time { return now },
+	}
+
+	cases := []struct {
+		period   string
+		expected map[string]Contribution
+	}{
+		{
+			period: "w",
+			expected: map[string]Contribution{
+				"dims":    {Rank: 1, Username: "dims", ContribCount: 1},
+				"liggitt": {Rank: 2, Username: "liggitt", ContribCount: 1},
+			},
+		},
+		{
+			period: "q",
+			expected: map[string]Contribution{
+				"dims":    {Rank: 1, Username: "dims", ContribCount: 2},
+				"liggitt": {Rank: 2, Username: "liggitt", ContribCount: 1},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		got, err := s.GetContributions(c.period)
+		if err != nil {
+			t.Errorf("unexpected error for period %s: %v", c.period, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("unexpected contributions for period %s:\n%+v\nexpected:\n%+v", c.period, got, c.expected)
+		}
+	}
+
+	if _, err := s.GetContributions("anno"); err == nil {
+		t.Errorf("expected an error for an unsupported period")
+	}
+}
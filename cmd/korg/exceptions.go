@@ -0,0 +1,333 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const expiresLayout = "2006-01-02"
+
+// Exception keeps a member from being reported as inactive by an audit.
+type Exception struct {
+	Username string `yaml:"username"`
+	Reason   string `yaml:"reason"`
+	// Approver is who agreed to the exception.
+	Approver string `yaml:"approver"`
+	// Expires is the day the exception stops applying, as YYYY-MM-DD. The
+	// exception never expires if unset.
+	Expires string `yaml:"expires"`
+	// Orgs limits the exception to some orgs, it applies to every org if
+	// unset.
+	Orgs []string `yaml:"orgs"`
+
+	// line the exception was read from
+	line      int
+	expiresAt time.Time
+}
+
+// exceptionFields lists the fields of an exception, in the order of CSV
+// columns.
+var exceptionFields = []string{"username", "reason", "approver", "expires", "orgs"}
+
+// appliesTo returns whether the exception covers orgName.
+func (e *Exception) appliesTo(orgName string) bool {
+	return len(e.Orgs) == 0 || stringInSlice(e.Orgs, orgName)
+}
+
+func (e *Exception) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+func (e *Exception) overlaps(other *Exception) bool {
+	if len(e.Orgs) == 0 || len(other.Orgs) == 0 {
+		return true
+	}
+	for _, org := range e.Orgs {
+		if stringInSlice(other.Orgs, org) {
+			return true
+		}
+	}
+	return false
+}
+
+// Exceptions holds the exceptions read from a file.
+type Exceptions struct {
+	path string
+	list []*Exception
+	// duplicates of an earlier exception lasting at least as long, which are
+	// ignored
+	duplicates []*Exception
+}
+
+// ReadExceptions reads exceptions from a CSV or YAML file, depending on its
+// extension. CSV files have a header naming their columns, out of username,
+// reason, approver, expires and orgs, where orgs are separated by semicolons.
+// YAML files hold a list of exceptions. Exceptions are validated, every
+// problem found is returned along with the line it is on. An exception
+// expiring later than an earlier one for the same user renews it, otherwise
+// it is a duplicate and ignored.
+func ReadExceptions(path string) (*Exceptions, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	var list []*Exception
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		list, err = parseExceptionsCSV(contents)
+	case ".yaml", ".yml":
+		list, err = parseExceptionsYAML(contents)
+	default:
+		return nil, fmt.Errorf("unknown exceptions file type %q, must be .csv or .yaml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid exceptions in %s: %s", path, err)
+	}
+
+	exceptions := &Exceptions{path: path}
+	var problems []string
+	for _, e := range list {
+		if err := e.validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s:%d: %s", path, e.line, err))
+			continue
+		}
+
+		if first := exceptions.find(e); first != nil {
+			exceptions.duplicates = append(exceptions.duplicates, e)
+			continue
+		}
+		exceptions.list = append(exceptions.list, e)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid exceptions:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return exceptions, nil
+}
+
+func (e *Exception) validate() error {
+	if e.Username == "" {
+		return errors.New("missing username")
+	}
+	if err := validateLoginSyntax(e.Username); err != nil {
+		return fmt.Errorf("invalid username %s: %s", e.Username, err)
+	}
+	if e.Reason == "" {
+		return fmt.Errorf("missing reason for %s", e.Username)
+	}
+	if e.Approver != "" {
+		if err := validateLoginSyntax(e.Approver); err != nil {
+			return fmt.Errorf("invalid approver %s: %s", e.Approver, err)
+		}
+	}
+	if e.Expires != "" {
+		expiresAt, err := time.Parse(expiresLayout, e.Expires)
+		if err != nil {
+			return fmt.Errorf("invalid expiry date %q, must be YYYY-MM-DD", e.Expires)
+		}
+		e.expiresAt = expiresAt
+	}
+	for _, org := range e.Orgs {
+		if org == "" {
+			return errors.New("empty org")
+		}
+	}
+
+	return nil
+}
+
+// outlasts returns whether e expires no earlier than other.
+func (e *Exception) outlasts(other *Exception) bool {
+	if e.expiresAt.IsZero() {
+		return true
+	}
+	return !other.expiresAt.IsZero() && !e.expiresAt.Before(other.expiresAt)
+}
+
+// find returns the exception for the same user as e with an overlapping
+// scope that lasts at least as long as e, if any.
+func (l *Exceptions) find(e *Exception) *Exception {
+	for _, other := range l.list {
+		if github.NormLogin(other.Username) == github.NormLogin(e.Username) && other.overlaps(e) && other.outlasts(e) {
+			return other
+		}
+	}
+	return nil
+}
+
+// lookup returns the exception covering username in orgName, if any. When
+// several do, the one expiring last is returned. Expired exceptions are
+// returned too, callers are expected to check expiry.
+func (l *Exceptions) lookup(username, orgName string) *Exception {
+	if l == nil {
+		return nil
+	}
+	var found *Exception
+	for _, e := range l.list {
+		if github.NormLogin(e.Username) != github.NormLogin(username) || !e.appliesTo(orgName) {
+			continue
+		}
+		if found == nil || !found.outlasts(e) {
+			found = e
+		}
+	}
+	return found
+}
+
+// orgs returns every org the exceptions are scoped to.
+func (l *Exceptions) orgs() []string {
+	orgs := []string{}
+	for _, e := range append(append([]*Exception{}, l.list...), l.duplicates...) {
+		for _, org := range e.Orgs {
+			if !stringInSlice(orgs, org) {
+				orgs = append(orgs, org)
+			}
+		}
+	}
+	return orgs
+}
+
+// warnings flags the exceptions needing attention: duplicates, which are
+// ignored, and expired ones, which no longer apply.
+func (l *Exceptions) warnings(now time.Time) []string {
+	warnings := []string{}
+	for _, e := range l.list {
+		if e.expired(now) {
+			warnings = append(warnings, fmt.Sprintf("%s:%d: exception for %s expired on %s", l.path, e.line, e.Username, e.Expires))
+		}
+	}
+	for _, e := range l.duplicates {
+		first := l.find(e)
+		warnings = append(warnings, fmt.Sprintf("%s:%d: duplicate exception for %s, ignored in favor of line %d", l.path, e.line, e.Username, first.line))
+	}
+	return warnings
+}
+
+func parseExceptionsCSV(contents []byte) ([]*Exception, error) {
+	r := csv.NewReader(bytes.NewReader(contents))
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("missing header")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !stringInSlice(exceptionFields, name) {
+			return nil, fmt.Errorf("line 1: unknown column %q, must be one of: %s", name, strings.Join(exceptionFields, ", "))
+		}
+		if _, found := columns[name]; found {
+			return nil, fmt.Errorf("line 1: duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"username", "reason"} {
+		if _, found := columns[required]; !found {
+			return nil, fmt.Errorf("line 1: missing %s column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, found := columns[name]; found {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var list []*Exception
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// csv errors carry their line
+			return nil, err
+		}
+
+		line, _ := r.FieldPos(0)
+		e := &Exception{
+			Username: field(record, "username"),
+			Reason:   field(record, "reason"),
+			Approver: field(record, "approver"),
+			Expires:  field(record, "expires"),
+			line:     line,
+		}
+		if orgs := field(record, "orgs"); orgs != "" {
+			for _, org := range strings.Split(orgs, ";") {
+				e.Orgs = append(e.Orgs, strings.TrimSpace(org))
+			}
+		}
+		list = append(list, e)
+	}
+
+	return list, nil
+}
+
+func parseExceptionsYAML(contents []byte) ([]*Exception, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: expected a list of exceptions", root.Line)
+	}
+
+	var list []*Exception
+	for _, item := range root.Content {
+		if item.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: expected an exception", item.Line)
+		}
+		for i := 0; i < len(item.Content); i += 2 {
+			key := item.Content[i]
+			if !stringInSlice(exceptionFields, key.Value) {
+				return nil, fmt.Errorf("line %d: unknown field %q, must be one of: %s", key.Line, key.Value, strings.Join(exceptionFields, ", "))
+			}
+		}
+
+		e := &Exception{line: item.Line}
+		if err := item.Decode(e); err != nil {
+			return nil, fmt.Errorf("line %d: %s", item.Line, err)
+		}
+		list = append(list, e)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestReadExceptions(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"exceptions.csv": `username,reason,approver,expires,orgs
+k8s-ci-robot,bot account,,,
+alice,on leave,bob,2024-03-01,kubernetes;etcd-io
+alice,on leave again,bob,,kubernetes-sigs
+K8s-CI-Robot,bot account,,,
+alice,back on leave,bob,2024-09-01,kubernetes
+alice,on leave,bob,2024-02-01,etcd-io
+`,
+		"exceptions.yaml": `- username: k8s-ci-robot
+  reason: bot account
+- username: alice
+  reason: on leave
+  approver: bob
+  expires: 2024-03-01
+  orgs:
+  - kubernetes
+  - etcd-io
+- username: alice
+  reason: on leave again
+  orgs: [kubernetes-sigs]
+- username: k8s-ci-robot
+  reason: bot account
+  orgs: [kubernetes]
+- username: alice
+  reason: back on leave
+  approver: bob
+  expires: 2024-09-01
+  orgs: [kubernetes]
+- username: alice
+  reason: on leave
+  approver: bob
+  expires: 2024-02-01
+  orgs: [etcd-io]
+`,
+	})
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	files := []struct {
+		name     string
+		warnings []string
+	}{
+		{
+			name: "exceptions.csv",
+			warnings: []string{
+				"exceptions.csv:3: exception for alice expired on 2024-03-01",
+				"exceptions.csv:5: duplicate exception for K8s-CI-Robot, ignored in favor of line 2",
+				"exceptions.csv:7: duplicate exception for alice, ignored in favor of line 3",
+			},
+		},
+		{
+			name: "exceptions.yaml",
+			warnings: []string{
+				"exceptions.yaml:3: exception for alice expired on 2024-03-01",
+				"exceptions.yaml:13: duplicate exception for k8s-ci-robot, ignored in favor of line 1",
+				"exceptions.yaml:21: duplicate exception for alice, ignored in favor of line 3",
+			},
+		},
+	}
+
+	cases := []struct {
+		username string
+		org      string
+		reason   string
+		expired  bool
+	}{
+		{username: "K8s-ci-robot", org: "kubernetes", reason: "bot account"},
+		{username: "alice", org: "kubernetes", reason: "back on leave"},
+		{username: "alice", org: "etcd-io", reason: "on leave", expired: true},
+		{username: "alice", org: "kubernetes-sigs", reason: "on leave again"},
+		{username: "alice", org: "other"},
+		{username: "bob", org: "kubernetes"},
+	}
+
+	for _, f := range files {
+		exceptions, err := ReadExceptions(filepath.Join(root, f.name))
+		if err != nil {
+			t.Errorf("unexpected error reading %s: %v", f.name, err)
+			continue
+		}
+
+		for _, c := range cases {
+			e := exceptions.lookup(c.username, c.org)
+			switch {
+			case c.reason == "" && e != nil:
+				t.Errorf("%s: expected no exception for %s in %s, got %+v", f.name, c.username, c.org, e)
+			case c.reason != "" && e == nil:
+				t.Errorf("%s: expected an exception for %s in %s", f.name, c.username, c.org)
+			case e != nil && (e.Reason != c.reason || e.expired(now) != c.expired):
+				t.Errorf("%s: unexpected exception for %s in %s: %+v", f.name, c.username, c.org, e)
+			}
+		}
+
+		expected := []string{}
+		for _, w := range f.warnings {
+			expected = append(expected, filepath.Join(root, w))
+		}
+		if warnings := exceptions.warnings(now); !reflect.DeepEqual(warnings, expected) {
+			t.Errorf("%s: unexpected warnings:\n%s\nexpected:\n%s", f.name, strings.Join(warnings, "\n"), strings.Join(expected, "\n"))
+		}
+	}
+}
+
+func TestReadExceptionsErrors(t *testing.T) {
+	cases := []struct {
+		file     string
+		contents string
+		expected string
+	}{
+		{
+			file:     "short-row.csv",
+			contents: "username,reason\nalice\n",
+			expected: "line 2",
+		},
+		{
+			file:     "missing-reason.csv",
+			contents: "username,reason\nalice,bot\nbob,\n",
+			expected: "missing-reason.csv:3: missing reason for bob",
+		},
+		{
+			file:     "bad-date.csv",
+			contents: "username,reason,expires\nalice,on leave,next week\n",
+			expected: "bad-date.csv:2: invalid expiry date",
+		},
+		{
+			file:     "unknown-column.csv",
+			contents: "username,reason,comment\n",
+			expected: `line 1: unknown column "comment"`,
+		},
+		{
+			file:     "no-header.csv",
+			contents: "",
+			expected: "missing header",
+		},
+		{
+			file:     "unknown-field.yaml",
+			contents: "- username: alice\n  reason: on leave\n- username: bob\n  reasn: typo\n",
+			expected: `line 4: unknown field "reasn"`,
+		},
+		{
+			file:     "bad-username.yaml",
+			contents: "- username: alice\n  reason: on leave\n\n- username: -bob\n  reason: typo\n",
+			expected: "bad-username.yaml:4: invalid username -bob",
+		},
+		{
+			file:     "exceptions.txt",
+			contents: "alice\n",
+			expected: "unknown exceptions file type",
+		},
+	}
+
+	for _, c := range cases {
+		root := testutil.WriteFiles(t, map[string]string{c.file: c.contents})
+		_, err := ReadExceptions(filepath.Join(root, c.file))
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("expected error containing %q reading %s, got %v", c.expected, c.file, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/prow/pkg/config/org"
+)
+
+// FormatOrgs formats the org.yaml and teams.yaml files of each org of o,
+// every org if none is selected, and returns the files changed, relative to
+// the repo root. With check, files are left untouched and the files needing
+// formatting are returned.
+func FormatOrgs(o Options, check bool) ([]string, error) {
+	orgs := o.Orgs
+	if len(orgs) == 0 {
+		var err error
+		orgs, err = discoverOrgs(o.RepoRoot)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changed := []string{}
+	for _, orgName := range orgs {
+		paths, err := orgConfigFiles(o.RepoRoot, orgName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			f, err := loadConfigFile(filepath.Join(o.RepoRoot, path))
+			if err != nil {
+				return nil, err
+			}
+
+			modified, err := formatConfigFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if !modified {
+				continue
+			}
+
+			changed = append(changed, path)
+			if !check {
+				if err := f.save(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return changed, nil
+}
+
+// formatConfigFile sorts and dedupes the org admins and members, and the team
+// maintainers and members of f, and normalizes the privacy of teams to the
+// plain `closed` every team must have, adding it if missing. Teams with
+// another privacy are left for korg lint to report. It returns whether f was
+// modified.
+func formatConfigFile(f *configFile) (bool, error) {
+	original := strings.Join(f.lines, "\n")
+
+	for _, field := range []string{"admins", "members"} {
+		if _, err := f.sortList(field); err != nil {
+			return false, err
+		}
+	}
+	if err := formatTeams(f, nil); err != nil {
+		return false, err
+	}
+
+	return strings.Join(f.lines, "\n") != original, nil
+}
+
+// formatTeams formats the teams under the team at the end of names, the top
+// level teams if names is empty.
+func formatTeams(f *configFile, names []string) error {
+	_, teams := f.lookup(teamKeys(names, "teams")...)
+	if teams == nil || teams.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	// edits reparse the file, so collect the names first
+	children := []string{}
+	for i := 0; i+1 < len(teams.Content); i += 2 {
+		if teams.Content[i+1].Kind == yaml.MappingNode {
+			children = append(children, teams.Content[i].Value)
+		}
+	}
+
+	for _, name := range children {
+		team := append(append([]string{}, names...), name)
+		for _, field := range []string{"maintainers", "members"} {
+			if _, err := f.sortList(teamKeys(team, field)...); err != nil {
+				return err
+			}
+		}
+
+		keys := teamKeys(team, "privacy")
+		key, privacy := f.lookup(keys...)
+		if key == nil || (privacy.Kind == yaml.ScalarNode && strings.EqualFold(privacy.Value, string(org.Closed))) {
+			if _, err := f.setScalar(string(org.Closed), keys...); err != nil {
+				return err
+			}
+		}
+
+		if err := formatTeams(f, team); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
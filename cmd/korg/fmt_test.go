@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestFormatConfigFile(t *testing.T) {
+	cases := []struct {
+		desc     string
+		config   string
+		expected string
+	}{
+		{
+			desc:     "formatted",
+			config:   testTeamsConfig,
+			expected: testTeamsConfig,
+		},
+		{
+			desc: "org lists",
+			config: `admins:
+- k8s-ci-robot
+- Alice
+members:
+- dave
+# joined first
+- Carol # shadow
+- bob
+- carol
+- Bob
+name: Test
+`,
+			expected: `admins:
+- Alice
+- k8s-ci-robot
+members:
+- bob
+# joined first
+- Carol # shadow
+- dave
+name: Test
+`,
+		},
+		{
+			desc: "teams",
+			config: `teams:
+  b-team:
+    members:
+    - zed
+    - alice
+    privacy: "Closed" # always
+    teams:
+      child:
+        maintainers:
+        - erin
+        - dave
+  a-team:
+    description: no privacy
+    repos:
+      foo: read
+  secret-team:
+    privacy: secret
+`,
+			expected: `teams:
+  b-team:
+    members:
+    - alice
+    - zed
+    privacy: closed # always
+    teams:
+      child:
+        maintainers:
+        - dave
+        - erin
+        privacy: closed
+  a-team:
+    description: no privacy
+    privacy: closed
+    repos:
+      foo: read
+  secret-team:
+    privacy: secret
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := writeTestConfig(t, tc.config)
+			modified, err := formatConfigFile(f)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := strings.Join(f.lines, "\n")
+			if got != tc.expected {
+				t.Errorf("unexpected config:\n%s\nexpected:\n%s", got, tc.expected)
+			}
+			if modified != (tc.config != tc.expected) {
+				t.Errorf("unexpected modified: %t", modified)
+			}
+		})
+	}
+}
+
+func TestFormatOrgs(t *testing.T) {
+	unformatted := "admins:\n- k8s-ci-robot\n- alice\n"
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/kubernetes/org.yaml":               unformatted,
+		"config/kubernetes/sig-foo/teams.yaml":     "teams:\n  foo:\n    members:\n    - bob\n    privacy: closed\n",
+		"config/kubernetes/sig-bar/teams.yaml":     "teams:\n  bar:\n    members:\n    - bob\n",
+		"config/other/org.yaml":                    unformatted,
+		"config/kubernetes/sig-bar/nested/foo.txt": "not a config",
+	})
+	o := Options{RepoRoot: root, Orgs: []string{"kubernetes"}}
+	expected := []string{"config/kubernetes/org.yaml", "config/kubernetes/sig-bar/teams.yaml"}
+
+	changed, err := FormatOrgs(o, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("unexpected files needing formatting: %v, expected %v", changed, expected)
+	}
+	contents, _ := os.ReadFile(filepath.Join(root, "config/kubernetes/org.yaml"))
+	if string(contents) != unformatted {
+		t.Errorf("check mode modified org.yaml:\n%s", contents)
+	}
+
+	changed, err = FormatOrgs(o, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("unexpected files formatted: %v, expected %v", changed, expected)
+	}
+	contents, _ = os.ReadFile(filepath.Join(root, "config/kubernetes/org.yaml"))
+	if string(contents) != "admins:\n- alice\n- k8s-ci-robot\n" {
+		t.Errorf("unexpected formatted org.yaml:\n%s", contents)
+	}
+	contents, _ = os.ReadFile(filepath.Join(root, "config/other/org.yaml"))
+	if string(contents) != unformatted {
+		t.Errorf("org not selected was formatted:\n%s", contents)
+	}
+
+	if changed, err = FormatOrgs(o, true); err != nil || len(changed) > 0 {
+		t.Errorf("expected formatted files to pass the check, got %v, %v", changed, err)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/yaml"
+)
+
+// identities resolves GitHub logins to the identity of their account, so that
+// logins differing in case, and logins of accounts that have since been
+// renamed, are matched with each other.
+type identities struct {
+	// current maps normalized previous logins to the normalized current
+	// login of their account
+	current map[string]string
+}
+
+// readAliases reads a YAML file mapping current logins to the list of logins
+// the account previously had, e.g.
+//
+//	new-login:
+//	- old-login
+func readAliases(path string) (*identities, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	var aliases map[string][]string
+	if err := yaml.Unmarshal(contents, &aliases); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal aliases from %s: %s", path, err)
+	}
+
+	ids, err := newIdentities(aliases)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aliases in %s: %s", path, err)
+	}
+	return ids, nil
+}
+
+func newIdentities(aliases map[string][]string) (*identities, error) {
+	ids := &identities{current: map[string]string{}}
+
+	logins := make([]string, 0, len(aliases))
+	for login := range aliases {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+
+	for _, login := range logins {
+		current := github.NormLogin(login)
+		for _, previous := range aliases[login] {
+			previous = github.NormLogin(previous)
+			if previous == current {
+				continue
+			}
+			if other, found := ids.current[previous]; found && other != current {
+				return nil, fmt.Errorf("%s is a previous login of both %s and %s", previous, other, current)
+			}
+			ids.current[previous] = current
+		}
+	}
+
+	// accounts renamed more than once may be listed as a chain of renames
+	for previous := range ids.current {
+		seen := map[string]bool{previous: true}
+		for login := ids.current[previous]; ; login = ids.current[login] {
+			if seen[login] {
+				return nil, fmt.Errorf("renames of %s loop back to it", previous)
+			}
+			seen[login] = true
+			if _, found := ids.current[login]; !found {
+				break
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// resolve returns the identity of login: its normalized current login.
+func (ids *identities) resolve(login string) string {
+	login = github.NormLogin(login)
+	if ids == nil {
+		return login
+	}
+
+	for {
+		current, found := ids.current[login]
+		if !found {
+			return login
+		}
+		login = current
+	}
+}
+
+// normalizeContributions keys contributions by identity, adding up the
+// contributions of logins resolving to the same identity.
+func (ids *identities) normalizeContributions(contribs map[string]Contribution) map[string]Contribution {
+	usernames := make([]string, 0, len(contribs))
+	for username := range contribs {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	normalized := make(map[string]Contribution, len(contribs))
+	for _, username := range usernames {
+		c := contribs[username]
+		id := ids.resolve(username)
+		if existing, found := normalized[id]; found {
+			existing.ContribCount += c.ContribCount
+			existing.Orgs = append(existing.Orgs, c.Orgs...)
+			if c.Rank != 0 && (existing.Rank == 0 || c.Rank < existing.Rank) {
+				existing.Rank = c.Rank
+			}
+			normalized[id] = existing
+			continue
+		}
+
+		c.Username = id
+		normalized[id] = c
+	}
+
+	return normalized
+}
+
+// String lists the renames known, for logging.
+func (ids *identities) String() string {
+	renames := []string{}
+	for previous, current := range ids.current {
+		renames = append(renames, fmt.Sprintf("%s -> %s", previous, current))
+	}
+	sort.Strings(renames)
+	return strings.Join(renames, ", ")
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestContributionCount(t *testing.T) {
+	contribs := map[string]Contribution{
+		"MadhavJivrajani": {Username: "MadhavJivrajani", ContribCount: 5},
+		"madhavjivrajani": {Username: "madhavjivrajani", ContribCount: 2},
+		"dims":            {Username: "dims", ContribCount: 7},
+		"Old-Login":       {Username: "Old-Login", ContribCount: 3},
+		"older-login":     {Username: "older-login", ContribCount: 4},
+		"new-login":       {Username: "new-login", ContribCount: 1},
+	}
+
+	ids, err := newIdentities(map[string][]string{
+		"New-Login": {"old-login"},
+		"old-login": {"@Older-Login"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		username string
+		ids      *identities
+		expected int
+	}{
+		{username: "MadhavJivrajani", expected: 7},
+		{username: "madhavjivrajani", expected: 7},
+		{username: "MADHAVJIVRAJANI", expected: 7},
+		{username: "@dims", expected: 7},
+		{username: "DIMS", expected: 7},
+		{username: "new-login", expected: 1},
+		{username: "old-login", expected: 3},
+		{username: "unknown", expected: 0},
+		{username: "New-Login", ids: ids, expected: 8},
+		{username: "OLD-LOGIN", ids: ids, expected: 8},
+		{username: "older-login", ids: ids, expected: 8},
+		{username: "Dims", ids: ids, expected: 7},
+	}
+
+	for _, c := range cases {
+		normalized := c.ids.normalizeContributions(contribs)
+		if got := contributionCount(normalized, c.ids, c.username); got != c.expected {
+			t.Errorf("expected %d contributions for %s (aliases: %v), got %d", c.expected, c.username, c.ids != nil, got)
+		}
+	}
+}
+
+func TestReadAliases(t *testing.T) {
+	cases := []struct {
+		contents string
+		valid    bool
+	}{
+		{contents: "new-login:\n- old-login\n", valid: true},
+		{contents: "new-login:\n- New-Login\n", valid: true},
+		{contents: "a:\n- old-login\nb:\n- Old-Login\n", valid: false},
+		{contents: "a:\n- b\nb:\n- a\n", valid: false},
+		{contents: "- a\n", valid: false},
+	}
+
+	for _, c := range cases {
+		root := testutil.WriteFiles(t, map[string]string{"aliases.yaml": c.contents})
+		_, err := readAliases(filepath.Join(root, "aliases.yaml"))
+		if c.valid && err != nil {
+			t.Errorf("unexpected error reading %q: %v", c.contents, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("expected error reading %q", c.contents)
+		}
+	}
+}
@@ -19,105 +19,270 @@ package main
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	validOrgs = []string{
-		"etcd-io",
-		"kubernetes",
-		"kubernetes-client",
-		"kubernetes-csi",
-		"kubernetes-sigs",
-	}
-
 	orgConfigPathFormat = "config/%s/org.yaml"
 
 	addHelpText = `
 Adds users to GitHub orgs and/or teams
 
-Add user to specified orgs:
+Add users to specified orgs:
 
 	korg add <github username> --org kubernetes --org kubernetes-sigs
 	korg add <github username> --org kubernetes,kubernetes-sigs
+	korg add <github username> <github username> --org kubernetes
+	korg add --from-file users.txt --org kubernetes
+
+Users already in an org are skipped, and every change is made in a single
+commit. The file passed with --from-file lists one username per line.
+
+Usernames are validated against GitHub's rules for logins. To also make sure
+the accounts exist, and to use their canonical casing, look them up in a local
+JSON cache and/or using the GitHub API:
+
+	korg add <github username> --org kubernetes --user-cache users.json
+	korg add <github username> --org kubernetes --check-github --github-token-path /etc/github/token
+
+The GitHub API reports renamed accounts as not found, so users are only
+reported as renamed when the cache sets renamed_to for their login.
+
+Add user to specified teams of an org:
+
+	korg add <github username> --org kubernetes --team sig-release/milestone-maintainers
+	korg add <github username> --org kubernetes --team milestone-maintainers
 
-Note: Adding to teams is currently unsupported.
+Teams are either referenced as <dir>/<team>, where dir is the directory holding
+the teams.yaml defining the team, or by their name alone. The user is added to
+the org as well, unless they are already a member.
 	`
 
 	removeHelpText = `
-Remove users from GitHub orgs
+Remove users from GitHub orgs and their teams
 
-Remove user to specified orgs:
+Remove users from specified orgs:
 
 	korg remove <github username> --org kubernetes --org kubernetes-sigs
+	korg remove --from-file users.txt --org kubernetes,kubernetes-sigs
+
+The user is also removed from every team of the specified orgs they are a
+member or maintainer of, whether defined in org.yaml or in a teams.yaml.
+	`
+
+	auditHelpText = `
+Audit GitHub org members
+
+Report members below an activity threshold, for each org selected with --org
+or every org by default. The audit section of the policy file overrides the
+threshold and period of an org or of its teams:
+
+	korg audit --org kubernetes --activity-threshold 5 --output-file audit.md
+
+Remove members below the activity threshold, after exceptions, from the orgs
+and all their teams in a single commit. Org admins, the approvers and reviewers
+of the org config, and members who approve in any OWNERS file or whose OWNERS
+files couldn't be looked up are kept. --apply implies --check-owners:
+
+	korg audit --org kubernetes --activity-threshold 5 --output-file audit.md --apply --confirm
+
+Contributions are read from devstats by default. To audit offline, read them
+from an exported JSON or CSV file, or count commits in local clones:
 
-Note: Removing from teams is currently unsupported.
+	korg audit --contributions-source file --contributions-file contributions.csv --output-file audit.md
+	korg audit --contributions-source git --git-repos ../kubernetes,../test-infra --period m3 --output-file audit.md
+
+Report the OWNERS files members are listed in, resolving OWNERS_ALIASES, by
+scanning local checkouts instead of searching cs.k8s.io:
+
+	korg audit --owners-dir ~/go/src/k8s.io --output-file audit.md
+
+Save a snapshot of the audit, to compare it with the next one using korg audit
+diff:
+
+	korg audit --output-file audit.md --snapshot-file audit-2024-06.json
+
+Draft notifications for inactive members from a text/template, see
+docs/sample-notify-template.md, either one markdown file per member or an issue
+body and comments mentioning at most --notify-mention-limit members each:
+
+	korg audit --output-file audit.md --notify-template notify.md --notify-dir notifications
+	korg audit --output-file audit.md --notify-template notify.md --notify-dir notifications --notify-mode issue
+	`
+
+	lintHelpText = `
+Check org configs for rule violations
+
+Check the orgs selected with --org, or every org by default, straight from
+their org.yaml, teams.yaml and OWNERS files, without building the merged
+config:
+
+	korg lint
+	korg lint --org kubernetes --format json
+
+Each finding names the rule it breaks and the file and line to fix. Warnings
+are reported but only errors make korg lint fail. List the rules with:
+
+	korg lint --list-rules
 	`
 
-	auditHelpText = "Audit GitHub org members"
+	fmtHelpText = `
+Sort and normalize org config files
+
+Format the org.yaml and every teams.yaml of the orgs selected with --org, or
+every org by default, listing the files changed:
+
+	korg fmt
+	korg fmt --org kubernetes
+
+Org admins and members, and team maintainers and members, are sorted
+case-insensitively and deduplicated. Teams get the plain "privacy: closed"
+every team must have. Comments are preserved and move along with the entry
+they precede.
+
+Check formatting without changing files, failing if any file needs it, e.g. in
+presubmits:
+
+	korg fmt --check
+	`
+
+	auditDiffHelpText = `
+Compare the snapshots of two audits, saved with korg audit --snapshot-file.
+
+Members are reported if they newly dropped below their activity bar, were
+already below it in the old audit, i.e. their grace period is over, recovered
+since the old audit, or were removed from the org since:
+
+	korg audit diff audit-2024-03.json audit-2024-06.json
+	`
 )
 
 type Options struct {
 	// global options
-	Confirm  bool
-	RepoRoot string
-	Orgs     []string
-	Teams    []string
+	Confirm    bool
+	RepoRoot   string
+	PolicyFile string
+	Orgs       []string
+	Teams      []string
+
+	// add and remove options
+	UsersFile string
+
+	// add options
+	UserCache       string
+	CheckGitHub     bool
+	GitHubTokenPath string
 
 	// audit options
 	AuditOptions
 }
 
-func AddMemberToOrgs(username string, options Options) error {
-	if invalidOrgs := findInvalidOrgs(options.Orgs); len(invalidOrgs) > 0 {
-		return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+func AddMembersToOrgs(usernames []string, options Options) error {
+	policy, err := readPolicy(options)
+	if err != nil {
+		return err
+	}
+
+	if readOnlyOrgs := findReadOnlyOrgs(policy, options.Orgs); len(readOnlyOrgs) > 0 {
+		return fmt.Errorf("orgs do not accept new members: %s", strings.Join(readOnlyOrgs, ", "))
 	}
 
 	if !options.Confirm {
 		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
 	}
 
-	configsModified := []string{}
-	for _, org := range options.Orgs {
-		fmt.Printf("adding %s to %s org\n", username, org)
+	lookup, err := newUserLookup(options)
+	if err != nil {
+		return err
+	}
 
-		relativeConfigPath := fmt.Sprintf(orgConfigPathFormat, org)
-		configPath := filepath.Join(options.RepoRoot, relativeConfigPath)
+	changes := newChangeSet(options.RepoRoot)
+	results := newSummary(append([]string{}, usernames...))
+	usernames, err = validateUsernames(usernames, lookup, results)
+	if err != nil {
+		return err
+	}
 
-		config, err := readConfig(configPath)
-		if err != nil {
-			return fmt.Errorf("reading config: %s", err)
+	if err := addMembersToOrgs(usernames, options, changes, results); err != nil {
+		return err
+	}
+
+	results.print("added")
+
+	if len(changes.modified) == 0 {
+		fmt.Println("nothing to change")
+		return nil
+	}
+
+	if options.Confirm {
+		if err := changes.save(); err != nil {
+			return fmt.Errorf("saving config: %s", err)
 		}
 
-		if stringInSliceCaseAgnostic(config.Members, username) || stringInSliceCaseAgnostic(config.Admins, username) {
-			return fmt.Errorf("user %s already exists in org %s", username, org)
+		fmt.Println("committing changes")
+
+		message := results.commitMessage("add", "to", options.Orgs)
+		if err := commitChanges(options.RepoRoot, changes.modified, message); err != nil {
+			return fmt.Errorf("committing changes: %s", err)
 		}
+	}
+	return nil
+}
 
-		newMembers := append(config.Members, username)
-		config.Members = newMembers
-		caseAgnosticSort(config.Members)
+// addMembersToOrgs adds usernames to the orgs of options, and to its teams if
+// any, recording the changes without saving them. Users already in an org are
+// only added to the teams, and org admins are never added as team members.
+func addMembersToOrgs(usernames []string, options Options, changes *changeSet, results *summary) error {
+	for _, org := range options.Orgs {
+		relativeConfigPath := fmt.Sprintf(orgConfigPathFormat, org)
+		file, err := changes.file(relativeConfigPath)
+		if err != nil {
+			return fmt.Errorf("reading config: %s", err)
+		}
 
-		if options.Confirm {
-			fmt.Printf("saving config for %s org\n", org)
-			if err := saveConfig(configPath, config); err != nil {
-				return fmt.Errorf("saving config: %s", err)
+		teams := []*teamLocation{}
+		for _, team := range options.Teams {
+			location, err := findTeam(options.RepoRoot, org, team)
+			if err != nil {
+				return err
 			}
+			teams = append(teams, location)
 		}
 
-		configsModified = append(configsModified, relativeConfigPath)
-	}
+		for _, username := range usernames {
+			fmt.Printf("adding %s to %s org\n", username, org)
 
-	if options.Confirm {
-		fmt.Println("committing changes")
+			role, source, err := orgRole(changes, org, username)
+			if err != nil {
+				return err
+			}
+			if role != "" {
+				if len(teams) == 0 {
+					results.skip(username, fmt.Sprintf("already exists in org %s (%s)", org, source))
+					continue
+				}
+
+				fmt.Printf("user %s already exists in org %s (%s), only adding to teams\n", username, org, source)
+			} else {
+				if _, err := file.addToList(username, "members"); err != nil {
+					return fmt.Errorf("adding %s to %s org: %s", username, org, err)
+				}
+
+				changes.markModified(relativeConfigPath)
+				results.changed(username, org)
+			}
 
-		message := fmt.Sprintf("add %s to %s", username, strings.Join(options.Orgs, ", "))
-		if err := commitChanges(options.RepoRoot, configsModified, message); err != nil {
-			return fmt.Errorf("committing changes: %s", err)
+			if len(teams) > 0 {
+				if err := AddMemberToTeams(username, org, teams, changes, results); err != nil {
+					return err
+				}
+			}
 		}
 	}
+
 	return nil
 }
 
@@ -130,32 +295,40 @@ func main() {
 	o := Options{}
 	rootCmd.PersistentFlags().BoolVar(&o.Confirm, "confirm", false, "confirm the changes")
 	rootCmd.PersistentFlags().StringVar(&o.RepoRoot, "root", ".", "root of the k/org repo")
+	rootCmd.PersistentFlags().StringVar(&o.PolicyFile, "policy-file", "", fmt.Sprintf("policy applied to each org. default: <root>/%s", defaultPolicyPath))
 	rootCmd.PersistentFlags().StringSliceVar(&o.Orgs, "org", []string{}, "orgs to add the user to")
 
 	addCmd := &cobra.Command{
 		Use:   "add",
 		Short: "Add members to org and/or teams",
 		Long:  addHelpText,
-		Args:  cobra.ExactArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 1 {
-				return fmt.Errorf("add only adds one user at a time. specified %d", len(args))
+			if len(args) == 0 && o.UsersFile == "" {
+				return fmt.Errorf("please specify atleast one user to add")
 			}
 
 			if len(o.Orgs) == 0 {
 				return fmt.Errorf("please specify atleast one org to add the user to")
 			}
 
-			if invalidOrgs := findInvalidOrgs(o.Orgs); len(invalidOrgs) > 0 {
-				return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+			if len(o.Teams) > 0 && len(o.Orgs) != 1 {
+				return fmt.Errorf("please specify exactly one org when adding the user to teams")
+			}
+
+			if err := validateOrgs(o.RepoRoot, o.Orgs); err != nil {
+				return err
 			}
 
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			user := args[0]
+			usernames, err := readUsernames(args, o.UsersFile)
+			if err != nil {
+				return err
+			}
+
 			if len(o.Orgs) > 0 {
-				return AddMemberToOrgs(user, o)
+				return AddMembersToOrgs(usernames, o)
 			}
 
 			return nil
@@ -164,43 +337,120 @@ func main() {
 
 	removeCmd := &cobra.Command{
 		Use:   "remove",
-		Short: "Remove members from org",
+		Short: "Remove members from org and its teams",
 		Long:  removeHelpText,
-		Args:  cobra.ExactArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if invalidOrgs := findInvalidOrgs(o.Orgs); len(invalidOrgs) > 0 {
-				return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+			if len(args) == 0 && o.UsersFile == "" {
+				return fmt.Errorf("please specify atleast one user to remove")
+			}
+
+			if err := validateOrgs(o.RepoRoot, o.Orgs); err != nil {
+				return err
 			}
 
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			user := args[0]
+			usernames, err := readUsernames(args, o.UsersFile)
+			if err != nil {
+				return err
+			}
+
 			if len(o.Orgs) > 0 {
-				return RemoveMemberFromOrgs(o, user)
+				return RemoveMembersFromOrgs(o, usernames)
 			}
 
 			return nil
 		},
 	}
 
+	// korg add flags
+	addCmd.Flags().StringSliceVar(&o.Teams, "team", []string{}, "teams to add the user to, as <dir>/<team> or <team>")
+	addCmd.Flags().StringVar(&o.UsersFile, "from-file", "", "file listing users to add, one per line")
+	addCmd.Flags().StringVar(&o.UserCache, "user-cache", "", "JSON file of known GitHub users to validate users against, renamed accounts having renamed_to set. default: none")
+	addCmd.Flags().BoolVar(&o.CheckGitHub, "check-github", false, "validate users against the GitHub API, which reports renamed accounts as not found. default: false")
+	addCmd.Flags().StringVar(&o.GitHubTokenPath, "github-token-path", "", "path to a GitHub token used with --check-github. default: none")
+
 	// korg remove flags
 	removeCmd.Flags().StringSliceVar(&o.Orgs, "org", []string{}, "orgs to remove the user from")
+	removeCmd.Flags().StringVar(&o.UsersFile, "from-file", "", "file listing users to remove, one per line")
 
 	auditCmd := &cobra.Command{
 		Use:   "audit",
 		Short: "Audit GitHub org members",
 		Long:  auditHelpText,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if invalidOrgs := findInvalidOrgs(o.Orgs); len(invalidOrgs) > 0 {
-				return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+			if err := validateOrgs(o.RepoRoot, o.Orgs); err != nil {
+				return err
 			}
 
 			if o.ActivityThreshold < 0 {
 				return fmt.Errorf("activity threshold cannot be negative")
 			}
 
-			// TODO: Check if exceptions file is of the right format, if defined
+			if o.OutputFile == "" {
+				return fmt.Errorf("please specify an output file")
+			}
+
+			if !stringInSlice(reportFormats, o.Format) {
+				return fmt.Errorf("unknown format %s, must be one of: %s", o.Format, strings.Join(reportFormats, ", "))
+			}
+
+			if err := validateContributionSource(o); err != nil {
+				return err
+			}
+
+			if o.OwnersConcurrency < 1 {
+				return fmt.Errorf("owners concurrency must be at least 1")
+			}
+
+			if o.OwnersRetries < 0 || o.OwnersQPS < 0 {
+				return fmt.Errorf("owners retries and qps cannot be negative")
+			}
+
+			if o.OwnersDir != "" {
+				if info, err := os.Stat(o.OwnersDir); err != nil || !info.IsDir() {
+					return fmt.Errorf("owners dir %s is not a directory", o.OwnersDir)
+				}
+				o.CheckOwners = true
+			}
+
+			// members who approve in OWNERS files are never removed, which
+			// requires knowing who they are
+			if o.Apply {
+				o.CheckOwners = true
+			}
+
+			if o.AliasesFile != "" {
+				if _, err := readAliases(o.AliasesFile); err != nil {
+					return err
+				}
+			}
+
+			if o.NotifyTemplate != "" {
+				if o.NotifyDir == "" {
+					return fmt.Errorf("please specify a directory to write notifications to with --notify-dir")
+				}
+				if !stringInSlice(notifyModes, o.NotifyMode) {
+					return fmt.Errorf("unknown notify mode %s, must be one of: %s", o.NotifyMode, strings.Join(notifyModes, ", "))
+				}
+				if o.NotifyMentionLimit < 1 {
+					return fmt.Errorf("notify mention limit must be at least 1")
+				}
+				if _, err := parseNotifyTemplate(o.NotifyTemplate); err != nil {
+					return err
+				}
+			}
+
+			if o.ExceptionsFile != "" {
+				exceptions, err := ReadExceptions(o.ExceptionsFile)
+				if err != nil {
+					return err
+				}
+				if err := validateOrgs(o.RepoRoot, exceptions.orgs()); err != nil {
+					return fmt.Errorf("invalid exceptions in %s: %s", o.ExceptionsFile, err)
+				}
+			}
 
 			return nil
 		},
@@ -212,15 +462,104 @@ func main() {
 	// korg audit flags
 	auditCmd.Flags().IntVar(&o.ActivityThreshold, "activity-threshold", 0, "minimum activity to be considered active. default: 0")
 	auditCmd.Flags().StringVar(&o.Period, "period", "y", "period to look back for activity. possible values are defined in https://github.com/cncf/devstats/blob/master/docs/periods.md. default: y (Year)")
-	auditCmd.Flags().StringVar(&o.OutputFile, "output-file", "", "parse owners files. default: none")
-	auditCmd.Flags().StringVar(&o.ExceptionsFile, "exceptions-file", "", "exceptions for removal. default: none")
+	auditCmd.Flags().StringVar(&o.OutputFile, "output-file", "", "file to write the audit report to")
+	auditCmd.Flags().StringVar(&o.Format, "format", formatMarkdown, fmt.Sprintf("format of the audit report, one of: %s", strings.Join(reportFormats, ", ")))
+	auditCmd.Flags().StringVar(&o.ExceptionsFile, "exceptions-file", "", "CSV or YAML file of members to keep despite being inactive, see docs/sample-exceptions.csv. default: none")
 	auditCmd.Flags().BoolVar(&o.CheckOwners, "check-owners", false, "parse owners files. default: false")
+	auditCmd.Flags().StringVar(&o.AliasesFile, "aliases-file", "", "YAML file mapping current GitHub logins to previous ones, so renamed accounts keep their contributions. default: none")
+	auditCmd.Flags().StringVar(&o.OwnersDir, "owners-dir", "", "directory of local repo checkouts to scan for OWNERS files instead of searching cs.k8s.io. implies --check-owners. default: none")
+	auditCmd.Flags().IntVar(&o.OwnersConcurrency, "owners-concurrency", 10, "number of owner lookups run in parallel")
+	auditCmd.Flags().DurationVar(&o.OwnersTimeout, "owners-timeout", 30*time.Second, "timeout of each owner lookup against cs.k8s.io")
+	auditCmd.Flags().IntVar(&o.OwnersRetries, "owners-retries", 3, "number of times a failed owner lookup is retried, with exponential backoff, before the user is reported as unknown")
+	auditCmd.Flags().Float64Var(&o.OwnersQPS, "owners-qps", 10, "maximum owner lookups per second. 0 means no limit")
 	auditCmd.Flags().BoolVar(&o.CheckTeams, "check-teams", false, "check which teams the user belongs to. default: false")
+	auditCmd.Flags().StringVar(&o.ContributionsSource, "contributions-source", sourceDevStats, fmt.Sprintf("where to read contributions from, one of: %s", strings.Join(contributionSources, ", ")))
+	auditCmd.Flags().StringVar(&o.ContributionsFile, "contributions-file", "", "JSON or CSV file of contributions, used with --contributions-source=file")
+	auditCmd.Flags().StringSliceVar(&o.GitRepos, "git-repos", []string{}, "local clones to count commits in, used with --contributions-source=git")
+	auditCmd.Flags().StringVar(&o.GitEmailsFile, "git-emails-file", "", "JSON object mapping commit author emails to GitHub logins, used with --contributions-source=git. default: only noreply emails are mapped")
+	auditCmd.Flags().StringVar(&o.SnapshotFile, "snapshot-file", "", "JSON file to save the classification of every audited member to, for korg audit diff. default: none")
+	auditCmd.Flags().StringVar(&o.NotifyTemplate, "notify-template", "", "text/template file rendered for each member below their activity bar, see docs/sample-notify-template.md. default: none")
+	auditCmd.Flags().StringVar(&o.NotifyDir, "notify-dir", "", "directory to write notifications to, used with --notify-template")
+	auditCmd.Flags().StringVar(&o.NotifyMode, "notify-mode", notifyModeFiles, fmt.Sprintf("how notifications are written, one of: %s. files writes <username>.md per member, issue writes issue.md and comment-<n>.md", strings.Join(notifyModes, ", ")))
+	auditCmd.Flags().IntVar(&o.NotifyMentionLimit, "notify-mention-limit", defaultMentionLimit, "maximum members mentioned in the issue or each comment, in issue mode")
+	auditCmd.Flags().BoolVar(&o.Apply, "apply", false, "remove members below the activity threshold from orgs and teams, in a single commit, keeping OWNERS approvers. implies --check-owners. honors --confirm. default: false")
 
 	// commands
+	var diffFormat string
+	auditDiffCmd := &cobra.Command{
+		Use:   "diff <old-snapshot> <new-snapshot>",
+		Short: "Compare the snapshots of two audits",
+		Long:  auditDiffHelpText,
+		Args:  cobra.ExactArgs(2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if diffFormat != formatMarkdown && diffFormat != formatJSON {
+				return fmt.Errorf("unknown format %s, must be one of: %s, %s", diffFormat, formatMarkdown, formatJSON)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return DiffAudits(os.Stdout, args[0], args[1], diffFormat)
+		},
+	}
+	auditDiffCmd.Flags().StringVar(&diffFormat, "format", formatMarkdown, fmt.Sprintf("format of the diff, one of: %s, %s", formatMarkdown, formatJSON))
+	auditCmd.AddCommand(auditDiffCmd)
+
+	var lintFormat string
+	var listRules bool
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check org configs for rule violations",
+		Long:  lintHelpText,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if lintFormat != formatText && lintFormat != formatJSON {
+				return fmt.Errorf("unknown format %s, must be one of: %s, %s", lintFormat, formatText, formatJSON)
+			}
+			return validateOrgs(o.RepoRoot, o.Orgs)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if listRules {
+				writeLintRules(os.Stdout)
+				return nil
+			}
+			// findings are the output, usage would only bury them
+			cmd.SilenceUsage = true
+			return LintOrgs(os.Stdout, o, lintFormat)
+		},
+	}
+	lintCmd.Flags().StringVar(&lintFormat, "format", formatText, fmt.Sprintf("format of the findings, one of: %s, %s", formatText, formatJSON))
+	lintCmd.Flags().BoolVar(&listRules, "list-rules", false, "list the rules checked and exit")
+
+	var fmtCheck bool
+	fmtCmd := &cobra.Command{
+		Use:   "fmt",
+		Short: "Sort and normalize org config files",
+		Long:  fmtHelpText,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateOrgs(o.RepoRoot, o.Orgs)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			changed, err := FormatOrgs(o, fmtCheck)
+			if err != nil {
+				return err
+			}
+			for _, path := range changed {
+				fmt.Println(path)
+			}
+
+			if fmtCheck && len(changed) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d file(s) need formatting, run korg fmt", len(changed))
+			}
+			return nil
+		},
+	}
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "list the files needing formatting without changing them, and fail if there are any")
+
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(fmtCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+const (
+	addTestOrg = `admins:
+- alice
+members:
+- bob
+- dave
+teams:
+  top:
+    members:
+    - bob
+    privacy: closed
+`
+	addTestRelease = `teams:
+  dup:
+    privacy: closed
+  release:
+    privacy: closed
+    teams:
+      child:
+        privacy: closed
+`
+	addTestFoo = `teams:
+  dup:
+    privacy: closed
+  foo:
+    privacy: closed
+`
+)
+
+func TestAddMembersToOrgs(t *testing.T) {
+	cases := []struct {
+		desc        string
+		usernames   []string
+		teams       []string
+		expected    map[string]string
+		changes     map[string][]string
+		skipped     map[string][]string
+		expectError string
+	}{
+		{
+			desc:      "org only",
+			usernames: []string{"carol"},
+			expected: map[string]string{
+				"org.yaml": strings.Replace(addTestOrg, "- bob\n- dave\n", "- bob\n- carol\n- dave\n", 1),
+			},
+			changes: map[string][]string{"carol": {"kubernetes"}},
+		},
+		{
+			desc:      "bare team name",
+			usernames: []string{"carol"},
+			teams:     []string{"foo"},
+			expected: map[string]string{
+				"org.yaml":           strings.Replace(addTestOrg, "- bob\n- dave\n", "- bob\n- carol\n- dave\n", 1),
+				"sig-foo/teams.yaml": strings.Replace(addTestFoo, "  foo:\n", "  foo:\n    members:\n    - carol\n", 1),
+			},
+			changes: map[string][]string{"carol": {"kubernetes", "kubernetes/foo"}},
+		},
+		{
+			desc:      "dir and team reference",
+			usernames: []string{"dave"},
+			teams:     []string{"sig-foo/dup"},
+			expected: map[string]string{
+				"sig-foo/teams.yaml": strings.Replace(addTestFoo, "  dup:\n", "  dup:\n    members:\n    - dave\n", 1),
+			},
+			changes: map[string][]string{"dave": {"kubernetes/dup"}},
+		},
+		{
+			desc:      "child team",
+			usernames: []string{"dave"},
+			teams:     []string{"child"},
+			expected: map[string]string{
+				"sig-release/teams.yaml": strings.Replace(addTestRelease, "      child:\n", "      child:\n        members:\n        - dave\n", 1),
+			},
+			changes: map[string][]string{"dave": {"kubernetes/release/child"}},
+		},
+		{
+			desc:      "already a member of the org and team",
+			usernames: []string{"Bob"},
+			teams:     []string{"top"},
+			skipped: map[string][]string{"Bob": {
+				"already exists in team top (ROOT/config/kubernetes/org.yaml:9)",
+			}},
+		},
+		{
+			desc:      "already a member of the org",
+			usernames: []string{"bob"},
+			skipped: map[string][]string{"bob": {
+				"already exists in org kubernetes (ROOT/config/kubernetes/org.yaml:4)",
+			}},
+		},
+		{
+			desc:      "admin",
+			usernames: []string{"alice"},
+			teams:     []string{"top"},
+			skipped: map[string][]string{"alice": {
+				"admin of org kubernetes (ROOT/config/kubernetes/org.yaml:2), can only be added to teams as a maintainer",
+			}},
+		},
+		{
+			desc:        "ambiguous team name",
+			usernames:   []string{"carol"},
+			teams:       []string{"dup"},
+			expectError: "team dup is defined more than once in org kubernetes: dup (config/kubernetes/sig-foo/teams.yaml:2), dup (config/kubernetes/sig-release/teams.yaml:2)",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			files := map[string]string{
+				"org.yaml":               addTestOrg,
+				"sig-release/teams.yaml": addTestRelease,
+				"sig-foo/teams.yaml":     addTestFoo,
+			}
+			repo := map[string]string{}
+			for path, contents := range files {
+				repo["config/kubernetes/"+path] = contents
+			}
+			root := testutil.WriteFiles(t, repo)
+
+			changes := newChangeSet(root)
+			results := newSummary(append([]string{}, tc.usernames...))
+			options := Options{RepoRoot: root, Orgs: []string{"kubernetes"}, Teams: tc.teams}
+			err := addMembersToOrgs(tc.usernames, options, changes, results)
+			if tc.expectError != "" {
+				if err == nil || err.Error() != tc.expectError {
+					t.Fatalf("expected error %q, got %v", tc.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := changes.save(); err != nil {
+				t.Fatal(err)
+			}
+
+			for path, original := range files {
+				expected, found := tc.expected[path]
+				if !found {
+					expected = original
+				}
+				got, err := os.ReadFile(filepath.Join(root, "config/kubernetes", path))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != expected {
+					t.Errorf("unexpected contents of %s:\n%s\nexpected:\n%s", path, got, expected)
+				}
+			}
+
+			expectedChanges := tc.changes
+			if expectedChanges == nil {
+				expectedChanges = map[string][]string{}
+			}
+			if !reflect.DeepEqual(results.changes, expectedChanges) {
+				t.Errorf("unexpected changes %v, expected %v", results.changes, expectedChanges)
+			}
+			expectedSkipped := map[string][]string{}
+			for username, reasons := range tc.skipped {
+				for _, reason := range reasons {
+					expectedSkipped[username] = append(expectedSkipped[username], strings.ReplaceAll(reason, "ROOT", root))
+				}
+			}
+			if !reflect.DeepEqual(results.skipped, expectedSkipped) {
+				t.Errorf("unexpected skips %v, expected %v", results.skipped, expectedSkipped)
+			}
+		})
+	}
+}
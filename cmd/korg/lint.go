@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/olekukonko/tablewriter"
+
+	"k8s.io/org/pkg/lint"
+)
+
+// LintOrgs checks the config of each org of o, every org if none is selected,
+// and writes what it finds to w. It fails if any finding is an error.
+func LintOrgs(w io.Writer, o Options, format string) error {
+	orgs := o.Orgs
+	if len(orgs) == 0 {
+		var err error
+		orgs, err = discoverOrgs(o.RepoRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	findings := []lint.Finding{}
+	for _, orgName := range orgs {
+		cfg, err := lint.LoadOrg(filepath.Join(o.RepoRoot, "config", orgName))
+		if err != nil {
+			return err
+		}
+		findings = append(findings, lint.Check(cfg)...)
+	}
+
+	errs := lint.Errors(findings)
+	switch format {
+	case formatJSON:
+		b, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal findings: %s", err)
+		}
+		fmt.Fprintln(w, string(b))
+	default:
+		for _, f := range findings {
+			fmt.Fprintln(w, f)
+		}
+		fmt.Fprintf(w, "%d error(s), %d warning(s) in %d org(s)\n", len(errs), len(findings)-len(errs), len(orgs))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("found %d error(s)", len(errs))
+	}
+	return nil
+}
+
+// writeLintRules lists the rules checked by korg lint.
+func writeLintRules(w io.Writer) {
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Rule", "Severity", "Description"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	for _, r := range lint.Rules {
+		table.Append([]string{r.ID, string(r.Severity), r.Description})
+	}
+	table.Render()
+}
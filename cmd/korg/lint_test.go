@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+const lintTestOwners = "approvers:\n- alice\n- bob\n- carol\n- dave\n- erin\n"
+
+func TestLintOrgs(t *testing.T) {
+	cases := []struct {
+		desc        string
+		orgConfig   string
+		summary     string
+		expectError bool
+	}{
+		{
+			desc:      "valid config",
+			orgConfig: "admins:\n- alice\n- k8s-ci-robot\nmembers:\n- bob\n- carol\n- dave\n- erin\n",
+			summary:   "0 error(s), 0 warning(s) in 1 org(s)",
+		},
+		{
+			desc:      "warnings only",
+			orgConfig: "admins:\n- alice\n- k8s-ci-robot\nmembers:\n- bob\n- carol\n- dave\n- erin\nteams:\n  empty:\n    privacy: closed\n",
+			summary:   "0 error(s), 1 warning(s) in 1 org(s)",
+		},
+		{
+			desc:        "errors",
+			orgConfig:   "admins:\n- alice\n- k8s-ci-robot\nmembers:\n- carol\n- bob\n- dave\n- erin\nteams:\n  empty:\n    privacy: closed\n",
+			summary:     "1 error(s), 1 warning(s) in 1 org(s)",
+			expectError: true,
+		},
+	}
+
+	for _, c := range cases {
+		root := testutil.WriteFiles(t, map[string]string{
+			"config/kubernetes/org.yaml": c.orgConfig,
+			"config/kubernetes/OWNERS":   lintTestOwners,
+		})
+
+		var b bytes.Buffer
+		err := LintOrgs(&b, Options{RepoRoot: root}, formatText)
+		if c.expectError && err == nil {
+			t.Errorf("%s: expected an error", c.desc)
+		}
+		if !c.expectError && err != nil {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+		}
+		if !strings.Contains(b.String(), c.summary) {
+			t.Errorf("%s: expected output to contain %q, got:\n%s", c.desc, c.summary, b.String())
+		}
+	}
+}
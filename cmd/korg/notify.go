@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const (
+	notifyModeFiles = "files"
+	notifyModeIssue = "issue"
+
+	// defaultMentionLimit is the number of users GitHub notifies when
+	// mentioned in a single issue or comment.
+	defaultMentionLimit = 50
+)
+
+var notifyModes = []string{notifyModeFiles, notifyModeIssue}
+
+// NotifyData is what notification templates are rendered with, for each
+// member found inactive.
+type NotifyData struct {
+	Username string
+	// Mention is the @-mention of the member.
+	Mention string
+	// Orgs the member was found inactive in.
+	Orgs []NotifyOrg
+	// Owners lists the OWNERS files the member is in, if owners were
+	// checked.
+	Owners []Ownership
+	// OwnersLink searches for the member in OWNERS files on cs.k8s.io.
+	OwnersLink string
+}
+
+// NotifyOrg is the audit of a member in one org.
+type NotifyOrg struct {
+	Name              string
+	Teams             []string
+	Contributions     int
+	ActivityThreshold int
+	Period            string
+}
+
+// parseNotifyTemplate parses the notification template at path. Templates
+// can use join, e.g. {{ join .Teams ", " }}.
+func parseNotifyTemplate(path string) (*template.Template, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Option("missingkey=error").
+		Parse(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse template %s: %s", path, err)
+	}
+
+	return tmpl, nil
+}
+
+// notifyData groups the audited entries of each member, which are per org,
+// sorted by username.
+func notifyData(inactive []UserInfo) []NotifyData {
+	byUser := map[string]*NotifyData{}
+	for _, member := range inactive {
+		id := github.NormLogin(member.Username)
+		data, found := byUser[id]
+		if !found {
+			data = &NotifyData{
+				Username:   member.Username,
+				Mention:    "@" + member.Username,
+				Owners:     member.Owners,
+				OwnersLink: fmt.Sprintf("https://go.k8s.io/owners/%s", member.Username),
+			}
+			byUser[id] = data
+		}
+
+		for _, org := range member.Orgs {
+			teams := append([]string{}, member.Teams[org]...)
+			sort.Strings(teams)
+			data.Orgs = append(data.Orgs, NotifyOrg{
+				Name:              org,
+				Teams:             teams,
+				Contributions:     member.Contributions,
+				ActivityThreshold: member.ActivityThreshold,
+				Period:            member.Period,
+			})
+		}
+	}
+
+	list := make([]NotifyData, 0, len(byUser))
+	for _, data := range byUser {
+		sort.Slice(data.Orgs, func(i, j int) bool {
+			return data.Orgs[i].Name < data.Orgs[j].Name
+		})
+		list = append(list, *data)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Username < list[j].Username
+	})
+
+	return list
+}
+
+// WriteNotifications renders the notification template of o for each member
+// found inactive into the notification directory. In files mode, each member
+// gets their own <username>.md. In issue mode, the notifications are
+// concatenated into issue.md, and once more members than the mention limit
+// are notified, into comment-<n>.md files to post on the issue, as GitHub
+// only notifies a limited number of users per issue or comment.
+func WriteNotifications(o Options, inactive []UserInfo) ([]string, error) {
+	tmpl, err := parseNotifyTemplate(o.NotifyTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(o.NotifyDir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create %s: %s", o.NotifyDir, err)
+	}
+
+	rendered := map[string]string{}
+	data := notifyData(inactive)
+	for _, d := range data {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, d); err != nil {
+			return nil, fmt.Errorf("unable to render notification for %s: %s", d.Username, err)
+		}
+		rendered[d.Username] = b.String()
+	}
+
+	files := map[string]string{}
+	switch o.NotifyMode {
+	case notifyModeIssue:
+		limit := o.NotifyMentionLimit
+		if limit < 1 {
+			limit = defaultMentionLimit
+		}
+		for i := 0; i < len(data); i += limit {
+			end := i + limit
+			if end > len(data) {
+				end = len(data)
+			}
+
+			sections := []string{}
+			for _, d := range data[i:end] {
+				sections = append(sections, strings.TrimRight(rendered[d.Username], "\n"))
+			}
+
+			name := "issue.md"
+			if i > 0 {
+				name = fmt.Sprintf("comment-%d.md", i/limit)
+			}
+			files[name] = strings.Join(sections, "\n\n") + "\n"
+		}
+	default:
+		for username, r := range rendered {
+			files[username+".md"] = r
+		}
+	}
+
+	written := []string{}
+	for name, contents := range files {
+		path := filepath.Join(o.NotifyDir, name)
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			return nil, fmt.Errorf("unable to write %s: %s", path, err)
+		}
+		written = append(written, path)
+	}
+
+	sort.Strings(written)
+	return written, nil
+}
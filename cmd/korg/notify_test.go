@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestWriteNotifications(t *testing.T) {
+	inactive := []UserInfo{
+		{Username: "bob", Orgs: []string{"kubernetes"}, Teams: map[string][]string{"kubernetes": {"release", "docs"}}, Contributions: 1, ActivityThreshold: 10, Period: "y"},
+		{Username: "alice", Orgs: []string{"kubernetes"}, Contributions: 0, ActivityThreshold: 10, Period: "y", Owners: []Ownership{{Path: "kubernetes/kubernetes/OWNERS", Role: roleApprover}}},
+		{Username: "Bob", Orgs: []string{"etcd-io"}, Contributions: 2, ActivityThreshold: 20, Period: "y"},
+		{Username: "carol", Orgs: []string{"kubernetes"}, Contributions: 3, ActivityThreshold: 10, Period: "y"},
+	}
+
+	cases := []struct {
+		name     string
+		mode     string
+		limit    int
+		expected map[string]string
+	}{
+		{
+			name: "files",
+			mode: notifyModeFiles,
+			expected: map[string]string{
+				"alice.md": "@alice: kubernetes=0/10 [kubernetes/kubernetes/OWNERS (approver)]\n",
+				"bob.md":   "@bob: etcd-io=2/20 kubernetes=1/10(docs,release) []\n",
+				"carol.md": "@carol: kubernetes=3/10 []\n",
+			},
+		},
+		{
+			name:  "issue with mentions batched",
+			mode:  notifyModeIssue,
+			limit: 2,
+			expected: map[string]string{
+				"issue.md":     "@alice: kubernetes=0/10 [kubernetes/kubernetes/OWNERS (approver)]\n\n@bob: etcd-io=2/20 kubernetes=1/10(docs,release) []\n",
+				"comment-1.md": "@carol: kubernetes=3/10 []\n",
+			},
+		},
+		{
+			name:  "issue within the mention limit",
+			mode:  notifyModeIssue,
+			limit: defaultMentionLimit,
+			expected: map[string]string{
+				"issue.md": "@alice: kubernetes=0/10 [kubernetes/kubernetes/OWNERS (approver)]\n\n@bob: etcd-io=2/20 kubernetes=1/10(docs,release) []\n\n@carol: kubernetes=3/10 []\n",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := testutil.WriteFiles(t, map[string]string{
+				"notify.md": `{{ .Mention }}:{{ range .Orgs }} {{ .Name }}={{ .Contributions }}/{{ .ActivityThreshold }}{{ if .Teams }}({{ join .Teams "," }}){{ end }}{{ end }} {{ .Owners }}` + "\n",
+			})
+			o := Options{AuditOptions: AuditOptions{
+				NotifyTemplate:     filepath.Join(root, "notify.md"),
+				NotifyDir:          filepath.Join(root, "out"),
+				NotifyMode:         tc.mode,
+				NotifyMentionLimit: tc.limit,
+			}}
+
+			written, err := WriteNotifications(o, inactive)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(written) != len(tc.expected) {
+				t.Errorf("unexpected files written: %v", written)
+			}
+
+			got := map[string]string{}
+			entries, err := os.ReadDir(o.NotifyDir)
+			if err != nil {
+				t.Fatalf("reading notifications: %v", err)
+			}
+			for _, entry := range entries {
+				contents, err := os.ReadFile(filepath.Join(o.NotifyDir, entry.Name()))
+				if err != nil {
+					t.Fatalf("reading notification: %v", err)
+				}
+				got[entry.Name()] = string(contents)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected notifications: %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseNotifyTemplateErrors(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"unclosed.md": "{{ .Username ",
+		"sample.md":   "",
+	})
+
+	if _, err := parseNotifyTemplate(filepath.Join(root, "unclosed.md")); err == nil {
+		t.Errorf("expected an error parsing an unclosed action")
+	}
+	if _, err := parseNotifyTemplate(filepath.Join(root, "missing.md")); err == nil {
+		t.Errorf("expected an error reading a missing template")
+	}
+	if _, err := parseNotifyTemplate("../../docs/sample-notify-template.md"); err != nil {
+		t.Errorf("unexpected error parsing the sample template: %v", err)
+	}
+}
@@ -0,0 +1,449 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hound-search/hound/client"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ownersFileName        = "OWNERS"
+	ownersAliasesFileName = "OWNERS_ALIASES"
+
+	roleApprover = "approver"
+	roleReviewer = "reviewer"
+
+	defaultHoundURL = "https://cs.k8s.io"
+
+	// filterAll is the OWNERS filter matching every file, equivalent to
+	// top-level approvers and reviewers.
+	filterAll = ".*"
+)
+
+// Ownership is a role held by a user in an OWNERS file.
+type Ownership struct {
+	// Path of the OWNERS file, relative to the directory that was scanned
+	// or prefixed by the repo when found through hound.
+	Path string `json:"path"`
+	// Role is either approver or reviewer, or empty if unknown.
+	Role string `json:"role,omitempty"`
+	// Filter is the regexp of the files the role applies to, if the role
+	// is limited to some files.
+	Filter string `json:"filter,omitempty"`
+}
+
+func (o Ownership) String() string {
+	s := o.Path
+	if o.Role != "" {
+		s = fmt.Sprintf("%s (%s)", s, o.Role)
+	}
+	if o.Filter != "" {
+		s = fmt.Sprintf("%s [%s]", s, o.Filter)
+	}
+	return s
+}
+
+// OwnersBackend finds the OWNERS files a user is listed in.
+type OwnersBackend interface {
+	Ownerships(username string) ([]Ownership, error)
+	String() string
+}
+
+// newOwnersBackend returns the owners backend configured by o, scanning local
+// checkouts if an owners directory is given and searching hound otherwise.
+func newOwnersBackend(o Options) (OwnersBackend, error) {
+	if o.OwnersDir != "" {
+		return newLocalOwners(o.OwnersDir)
+	}
+
+	h := newHoundOwners()
+	if o.OwnersTimeout > 0 {
+		h.client.Timeout = o.OwnersTimeout
+	}
+	return h, nil
+}
+
+type ownersFilter struct {
+	Approvers []string `json:"approvers,omitempty"`
+	Reviewers []string `json:"reviewers,omitempty"`
+}
+
+type ownersFile struct {
+	Approvers []string                `json:"approvers,omitempty"`
+	Reviewers []string                `json:"reviewers,omitempty"`
+	Filters   map[string]ownersFilter `json:"filters,omitempty"`
+}
+
+type ownersAliasesFile struct {
+	Aliases map[string][]string `json:"aliases,omitempty"`
+}
+
+// readOwnersFile reads the OWNERS file at path. A missing file has no owners.
+func readOwnersFile(path string) (*ownersFile, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &ownersFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	var owners ownersFile
+	if err := yaml.Unmarshal(contents, &owners); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal owners from %s: %s", path, err)
+	}
+
+	return &owners, nil
+}
+
+func readOwnersAliasesFile(path string) (map[string][]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	var aliases ownersAliasesFile
+	if err := yaml.Unmarshal(contents, &aliases); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal aliases from %s: %s", path, err)
+	}
+
+	normalized := make(map[string][]string, len(aliases.Aliases))
+	for alias, users := range aliases.Aliases {
+		normalized[strings.ToLower(strings.TrimSpace(alias))] = users
+	}
+	return normalized, nil
+}
+
+// houndOwners searches the OWNERS files of all repos indexed by a hound
+// instance. Hound only returns the files mentioning a user, so the roles are
+// unknown.
+type houndOwners struct {
+	client *http.Client
+	url    string
+}
+
+func newHoundOwners() *houndOwners {
+	return &houndOwners{
+		client: &http.Client{Timeout: time.Minute},
+		url:    defaultHoundURL,
+	}
+}
+
+func (h *houndOwners) String() string {
+	return h.url
+}
+
+func (h *houndOwners) Ownerships(username string) ([]Ownership, error) {
+	u := fmt.Sprintf("%s/api/v1/search?stats=fosho&repos=*&rng=:20&q=%s&i=fosho&files=OWNERS&excludeFiles=vendor/", h.url, url.QueryEscape(username))
+	resp, err := h.client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status code from hound for %s: %d", username, resp.StatusCode)
+	}
+
+	var r client.Response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("unable to parse json from hound: %w", err)
+	}
+
+	ownerships := []Ownership{}
+	for repo, result := range r.Results {
+		if result == nil {
+			continue
+		}
+		for _, match := range result.Matches {
+			ownerships = append(ownerships, Ownership{Path: path.Join(repo, match.Filename)})
+		}
+	}
+
+	sortOwnerships(ownerships)
+	return ownerships, nil
+}
+
+// localOwners indexes the OWNERS files found in a directory of local repo
+// checkouts. Aliases are resolved using the OWNERS_ALIASES file closest to
+// each OWNERS file, which is usually at the root of its repo.
+type localOwners struct {
+	dir string
+	// owners maps normalized logins to their ownerships
+	owners map[string][]Ownership
+}
+
+func newLocalOwners(dir string) (*localOwners, error) {
+	var ownersPaths []string
+	aliasesDirs := map[string]string{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", "vendor", "node_modules":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch d.Name() {
+		case ownersFileName:
+			ownersPaths = append(ownersPaths, p)
+		case ownersAliasesFileName:
+			aliasesDirs[filepath.Dir(p)] = p
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %s", dir, err)
+	}
+
+	aliases := map[string]map[string][]string{}
+	for aliasesDir, p := range aliasesDirs {
+		a, err := readOwnersAliasesFile(p)
+		if err != nil {
+			return nil, err
+		}
+		aliases[aliasesDir] = a
+	}
+
+	l := &localOwners{dir: dir, owners: map[string][]Ownership{}}
+	for _, p := range ownersPaths {
+		owners, err := readOwnersFile(p)
+		if err != nil {
+			return nil, err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+
+		resolve := aliasResolver(aliases, dir, filepath.Dir(p))
+		l.add(resolve, owners.Approvers, Ownership{Path: rel, Role: roleApprover})
+		l.add(resolve, owners.Reviewers, Ownership{Path: rel, Role: roleReviewer})
+		for filter, f := range owners.Filters {
+			if filter == filterAll {
+				filter = ""
+			}
+			l.add(resolve, f.Approvers, Ownership{Path: rel, Role: roleApprover, Filter: filter})
+			l.add(resolve, f.Reviewers, Ownership{Path: rel, Role: roleReviewer, Filter: filter})
+		}
+	}
+
+	for login := range l.owners {
+		sortOwnerships(l.owners[login])
+	}
+
+	return l, nil
+}
+
+// aliasResolver returns a function expanding an OWNERS entry of a file in
+// ownersDir into the users it stands for, using the closest OWNERS_ALIASES up
+// to root.
+func aliasResolver(aliases map[string]map[string][]string, root, ownersDir string) func(string) []string {
+	var closest map[string][]string
+	for d := ownersDir; ; d = filepath.Dir(d) {
+		if a, found := aliases[d]; found {
+			closest = a
+			break
+		}
+		if d == root || d == filepath.Dir(d) {
+			break
+		}
+	}
+
+	return func(entry string) []string {
+		if users, found := closest[strings.ToLower(entry)]; found {
+			return users
+		}
+		return []string{entry}
+	}
+}
+
+// add records ownership for every user listed in entries.
+func (l *localOwners) add(resolve func(string) []string, entries []string, ownership Ownership) {
+	for _, entry := range entries {
+		for _, user := range resolve(strings.TrimSpace(entry)) {
+			login := github.NormLogin(strings.TrimSpace(user))
+			// skip empty entries and teams, e.g. org/team
+			if login == "" || strings.Contains(login, "/") {
+				continue
+			}
+
+			if !ownershipInSlice(l.owners[login], ownership) {
+				l.owners[login] = append(l.owners[login], ownership)
+			}
+		}
+	}
+}
+
+func (l *localOwners) String() string {
+	return l.dir
+}
+
+func (l *localOwners) Ownerships(username string) ([]Ownership, error) {
+	return append([]Ownership{}, l.owners[github.NormLogin(username)]...), nil
+}
+
+func ownershipInSlice(ownerships []Ownership, ownership Ownership) bool {
+	for _, o := range ownerships {
+		if o == ownership {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortOwnerships(ownerships []Ownership) {
+	sort.Slice(ownerships, func(i, j int) bool {
+		if ownerships[i].Path != ownerships[j].Path {
+			return ownerships[i].Path < ownerships[j].Path
+		}
+		if ownerships[i].Role != ownerships[j].Role {
+			return ownerships[i].Role < ownerships[j].Role
+		}
+		return ownerships[i].Filter < ownerships[j].Filter
+	})
+}
+
+// ownersLookup configures how lookupOwners queries an OwnersBackend.
+type ownersLookup struct {
+	// concurrency is the number of lookups run in parallel
+	concurrency int
+	// qps limits the rate of lookups, if set
+	qps float64
+	// retries is the number of times a failed lookup is retried
+	retries int
+	// backoff is the wait before the first retry, doubled for each
+	// following one
+	backoff time.Duration
+}
+
+func newOwnersLookup(o Options) ownersLookup {
+	return ownersLookup{
+		concurrency: o.OwnersConcurrency,
+		qps:         o.OwnersQPS,
+		retries:     o.OwnersRetries,
+		backoff:     time.Second,
+	}
+}
+
+// interval returns the wait between two lookups to stay under qps. It is at
+// least a nanosecond, as tickers panic on shorter intervals, which very high
+// rates round down to.
+func (l ownersLookup) interval() time.Duration {
+	interval := time.Duration(float64(time.Second) / l.qps)
+	if interval < time.Nanosecond {
+		return time.Nanosecond
+	}
+	return interval
+}
+
+// lookupOwners fills the ownerships of members using backend. Lookups that
+// still fail after retries don't abort the audit: the member is marked as
+// unknown with OwnerLookupError set. It returns the number of failed lookups.
+func lookupOwners(backend OwnersBackend, members []UserInfo, l ownersLookup) int {
+	concurrency := l.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var throttle <-chan time.Time
+	if l.qps > 0 {
+		ticker := time.NewTicker(l.interval())
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				member := &members[i]
+				ownerships, err := l.lookup(backend, member.Username, throttle)
+				if err != nil {
+					fmt.Printf("unable to check if user %s is owner, marking as unknown: %s\n", member.Username, err)
+					member.OwnerLookupError = err.Error()
+					mu.Lock()
+					failed++
+					mu.Unlock()
+					continue
+				}
+
+				member.Owners = ownerships
+				member.IsOwner = len(ownerships) > 0
+				fmt.Printf("checking if user %s is owner: %v\n", member.Username, member.IsOwner)
+			}
+		}()
+	}
+
+	for i := range members {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return failed
+}
+
+// lookup looks the ownerships of username up, retrying with exponential
+// backoff.
+func (l ownersLookup) lookup(backend OwnersBackend, username string, throttle <-chan time.Time) ([]Ownership, error) {
+	backoff := l.backoff
+	for attempt := 0; ; attempt++ {
+		if throttle != nil {
+			<-throttle
+		}
+
+		ownerships, err := backend.Ownerships(username)
+		if err == nil {
+			return ownerships, nil
+		}
+		if attempt >= l.retries {
+			return nil, fmt.Errorf("giving up after %d attempts: %s", attempt+1, err)
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
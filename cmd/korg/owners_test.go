@@ -0,0 +1,247 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestLocalOwners(t *testing.T) {
+	dir := testutil.WriteFiles(t, map[string]string{
+		"kubernetes/OWNERS_ALIASES": `aliases:
+  sig-node-approvers:
+  - Dchen1107
+  - derekwaynecarr
+`,
+		"kubernetes/OWNERS": `approvers:
+- sig-node-approvers
+- "@liggitt"
+reviewers:
+- dims
+`,
+		"kubernetes/pkg/kubelet/OWNERS": `options:
+  no_parent_owners: true
+filters:
+  ".*":
+    approvers:
+    - sig-node-approvers
+  "\\.go$":
+    reviewers:
+    - dims
+labels:
+- sig/node
+`,
+		"kubernetes/vendor/foo/OWNERS": `approvers:
+- dims
+`,
+		"test-infra/OWNERS_ALIASES": `aliases:
+  sig-node-approvers:
+  - someone-else
+`,
+		"test-infra/OWNERS": `approvers:
+- sig-node-approvers
+- kubernetes/sig-testing
+`,
+	})
+
+	owners, err := newLocalOwners(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		username string
+		expected []Ownership
+	}{
+		{
+			username: "dchen1107",
+			expected: []Ownership{
+				{Path: "kubernetes/OWNERS", Role: roleApprover},
+				{Path: "kubernetes/pkg/kubelet/OWNERS", Role: roleApprover},
+			},
+		},
+		{
+			username: "Dims",
+			expected: []Ownership{
+				{Path: "kubernetes/OWNERS", Role: roleReviewer},
+				{Path: "kubernetes/pkg/kubelet/OWNERS", Role: roleReviewer, Filter: `\.go$`},
+			},
+		},
+		{
+			username: "liggitt",
+			expected: []Ownership{
+				{Path: "kubernetes/OWNERS", Role: roleApprover},
+			},
+		},
+		{
+			username: "someone-else",
+			expected: []Ownership{
+				{Path: "test-infra/OWNERS", Role: roleApprover},
+			},
+		},
+		{
+			username: "sig-node-approvers",
+			expected: []Ownership{},
+		},
+	}
+
+	for _, c := range cases {
+		got, err := owners.Ownerships(c.username)
+		if err != nil {
+			t.Errorf("unexpected error looking up %s: %v", c.username, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("unexpected ownerships of %s:\n%+v\nexpected:\n%+v", c.username, got, c.expected)
+		}
+	}
+}
+
+func TestHoundOwners(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "dims" {
+			w.Write([]byte(`{"Results": {}, "Stats": {"FilesOpened": 0, "Duration": 1}}`))
+			return
+		}
+		w.Write([]byte(`{"Results": {"kubernetes": {"Matches": [{"Filename": "pkg/OWNERS"}, {"Filename": "OWNERS"}]}}, "Stats": {"FilesOpened": 2, "Duration": 1}}`))
+	}))
+	defer server.Close()
+
+	h := newHoundOwners()
+	h.url = server.URL
+
+	got, err := h.Ownerships("dims")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Ownership{{Path: "kubernetes/OWNERS"}, {Path: "kubernetes/pkg/OWNERS"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected ownerships:\n%+v\nexpected:\n%+v", got, expected)
+	}
+
+	got, err = h.Ownerships("nobody")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected no ownerships, got %+v", got)
+	}
+}
+
+func TestLookupOwners(t *testing.T) {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username := r.URL.Query().Get("q")
+
+		mu.Lock()
+		attempts[username]++
+		attempt := attempts[username]
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		switch {
+		case username == "broken":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		case username == "flaky" && attempt == 1:
+			http.Error(w, "boom", http.StatusBadGateway)
+		case username == "slow" && attempt == 1:
+			time.Sleep(200 * time.Millisecond)
+			fallthrough
+		case username == "flaky" || username == "slow":
+			fmt.Fprintf(w, `{"Results": {"kubernetes": {"Matches": [{"Filename": "OWNERS"}]}}}`)
+		default:
+			fmt.Fprintf(w, `{"Results": {}}`)
+		}
+	}))
+	defer server.Close()
+
+	h := newHoundOwners()
+	h.url = server.URL
+	h.client.Timeout = 100 * time.Millisecond
+
+	members := []UserInfo{{Username: "broken"}, {Username: "flaky"}, {Username: "slow"}}
+	for i := 0; i < 10; i++ {
+		members = append(members, UserInfo{Username: fmt.Sprintf("user-%d", i)})
+	}
+
+	failed := lookupOwners(h, members, ownersLookup{concurrency: 3, retries: 2, backoff: time.Millisecond})
+	if failed != 1 {
+		t.Errorf("expected 1 failed lookup, got %d", failed)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("expected at most 3 concurrent lookups, got %d", maxInFlight)
+	}
+	if attempts["broken"] != 3 {
+		t.Errorf("expected 3 attempts for a failing lookup, got %d", attempts["broken"])
+	}
+
+	for _, m := range members {
+		switch m.Username {
+		case "broken":
+			if m.OwnerLookupError == "" || m.IsOwner {
+				t.Errorf("expected %s to be marked unknown, got %+v", m.Username, m)
+			}
+		case "flaky", "slow":
+			if m.OwnerLookupError != "" || !m.IsOwner {
+				t.Errorf("expected %s to be an owner after retrying, got %+v", m.Username, m)
+			}
+		default:
+			if m.OwnerLookupError != "" || m.IsOwner {
+				t.Errorf("expected %s not to be an owner, got %+v", m.Username, m)
+			}
+		}
+	}
+}
+
+func TestOwnersLookupInterval(t *testing.T) {
+	cases := []struct {
+		qps      float64
+		expected time.Duration
+	}{
+		{qps: 10, expected: 100 * time.Millisecond},
+		{qps: 0.5, expected: 2 * time.Second},
+		{qps: 1e12, expected: time.Nanosecond},
+		{qps: math.Inf(1), expected: time.Nanosecond},
+	}
+
+	for _, c := range cases {
+		if got := (ownersLookup{qps: c.qps}).interval(); got != c.expected {
+			t.Errorf("unexpected interval for %v qps: %v, expected %v", c.qps, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+const defaultPolicyPath = "config/policy.yaml"
+
+// Policy holds the rules korg applies to each org.
+type Policy struct {
+	Orgs map[string]OrgPolicy `json:"orgs,omitempty"`
+}
+
+// OrgPolicy holds the rules korg applies to a single org. Orgs missing from
+// the policy file get the zero value.
+type OrgPolicy struct {
+	// ReadOnly orgs don't accept new members, e.g. retired orgs.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Audit sets the activity members of the org need to be kept.
+	Audit *AuditPolicy `json:"audit,omitempty"`
+}
+
+// AuditRule is the activity a member needs over a lookback period to be
+// considered active. Unset fields fall back to the flags of korg audit.
+type AuditRule struct {
+	ActivityThreshold *int   `json:"activityThreshold,omitempty"`
+	Period            string `json:"period,omitempty"`
+}
+
+// AuditPolicy is the audit rule of an org, along with the rules of teams
+// held to a different bar. Team rules replace the rule of the org for the
+// members of the team. Members of several such teams are held to the most
+// lenient of their rules.
+type AuditPolicy struct {
+	AuditRule `json:",inline"`
+	// Teams maps the names of top-level teams to their rule. Unset fields
+	// fall back to the rule of the org.
+	Teams map[string]AuditRule `json:"teams,omitempty"`
+}
+
+// activityBar is an audit rule with every field resolved.
+type activityBar struct {
+	threshold int
+	period    string
+}
+
+func (b activityBar) String() string {
+	return fmt.Sprintf("more than %d contributions over the %q period", b.threshold, b.period)
+}
+
+// apply returns bar overridden by the fields set in rule.
+func (b activityBar) apply(rule AuditRule) activityBar {
+	if rule.ActivityThreshold != nil {
+		b.threshold = *rule.ActivityThreshold
+	}
+	if rule.Period != "" {
+		b.period = rule.Period
+	}
+	return b
+}
+
+// auditBars returns the activity bars a member of orgName on teams is held
+// to, i.e. the bars of the teams with their own rule or, if there are none,
+// the bar of the org. The member is active if they meet any of them.
+func (p *Policy) auditBars(o Options, orgName string, teams []string) []activityBar {
+	orgBar := activityBar{threshold: o.ActivityThreshold, period: o.Period}
+	audit := p.Orgs[orgName].Audit
+	if audit == nil {
+		return []activityBar{orgBar}
+	}
+
+	orgBar = orgBar.apply(audit.AuditRule)
+	teamBars := []activityBar{}
+	for _, team := range teams {
+		if rule, found := audit.Teams[team]; found {
+			teamBars = append(teamBars, orgBar.apply(rule))
+		}
+	}
+	if len(teamBars) == 0 {
+		return []activityBar{orgBar}
+	}
+
+	return teamBars
+}
+
+// policyPath returns the policy file to use, defaulting to the one in the
+// repo.
+func (o Options) policyPath() string {
+	if o.PolicyFile != "" {
+		return o.PolicyFile
+	}
+
+	return filepath.Join(o.RepoRoot, defaultPolicyPath)
+}
+
+// readPolicy reads the policy file of o. A missing file is only an error if
+// it was explicitly asked for. Every org of the policy must be configured in
+// the repo.
+func readPolicy(o Options) (*Policy, error) {
+	path := o.policyPath()
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && o.PolicyFile == "" {
+		return &Policy{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(contents, &policy, yaml.DisallowUnknownFields); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal policy from %s: %s", path, err)
+	}
+
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy in %s: %s", path, err)
+	}
+
+	if err := policy.validateOrgs(o.RepoRoot, path); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// validateOrgs makes sure every org of the policy read from path is
+// configured in the repo, so that a typo doesn't silently leave an org
+// without its policy.
+func (p *Policy) validateOrgs(repoRoot, path string) error {
+	if len(p.Orgs) == 0 {
+		return nil
+	}
+
+	validOrgs, err := discoverOrgs(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	// the policy is parsed again to find the line of each org
+	f, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	_, orgs := f.lookup("orgs")
+
+	var problems []string
+	for i := 0; i+1 < len(orgs.Content); i += 2 {
+		key := orgs.Content[i]
+		if !stringInSlice(validOrgs, key.Value) {
+			problems = append(problems, fmt.Sprintf("%s:%d: unknown org %s", path, key.Line, key.Value))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid policy:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+func (p *Policy) validate() error {
+	for orgName, orgPolicy := range p.Orgs {
+		if orgPolicy.Audit == nil {
+			continue
+		}
+
+		if t := orgPolicy.Audit.ActivityThreshold; t != nil && *t < 0 {
+			return fmt.Errorf("activity threshold of org %s cannot be negative", orgName)
+		}
+		for team, rule := range orgPolicy.Audit.Teams {
+			if t := rule.ActivityThreshold; t != nil && *t < 0 {
+				return fmt.Errorf("activity threshold of team %s in org %s cannot be negative", team, orgName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// discoverOrgs returns the orgs configured in the repo, i.e. every directory
+// under config/ holding an org.yaml.
+func discoverOrgs(repoRoot string) ([]string, error) {
+	orgs, err := orgconfig.Discover(filepath.Join(repoRoot, "config"))
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) == 0 {
+		return nil, fmt.Errorf("no orgs found under %s", filepath.Join(repoRoot, "config"))
+	}
+
+	return orgs, nil
+}
+
+// validateOrgs makes sure every org in orgs is configured in the repo.
+func validateOrgs(repoRoot string, orgs []string) error {
+	validOrgs, err := discoverOrgs(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	if invalidOrgs := findInvalidOrgs(validOrgs, orgs); len(invalidOrgs) > 0 {
+		return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+	}
+
+	return nil
+}
+
+// findReadOnlyOrgs returns the orgs in orgs that don't accept new members.
+func findReadOnlyOrgs(policy *Policy, orgs []string) []string {
+	readOnly := []string{}
+	for _, org := range orgs {
+		if policy.Orgs[org].ReadOnly {
+			readOnly = append(readOnly, org)
+		}
+	}
+
+	return readOnly
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestDiscoverOrgs(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/org-b/org.yaml":           "name: B\n",
+		"config/org-a/org.yaml":           "name: A\n",
+		"config/org-a/sig-foo/teams.yaml": "teams: {}\n",
+		"config/not-an-org/OWNERS":        "approvers: []\n",
+	})
+
+	orgs, err := discoverOrgs(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"org-a", "org-b"}; !reflect.DeepEqual(orgs, expected) {
+		t.Errorf("unexpected orgs: got %v, expected %v", orgs, expected)
+	}
+
+	if err := validateOrgs(root, []string{"org-a", "not-an-org"}); err == nil {
+		t.Errorf("expected error for an org without org.yaml")
+	}
+}
+
+func TestReadPolicy(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/policy.yaml":          "orgs:\n  org-retired:\n    readOnly: true\n",
+		"config/org-a/org.yaml":       "name: A\n",
+		"config/org-retired/org.yaml": "name: Retired\n",
+		"bad-policy.yaml":             "orgs:\n  org-retired:\n    read-only: true\n",
+		"typo-policy.yaml":            "orgs:\n  org-a: {}\n  org-retird:\n    readOnly: true\n  org-b: {}\n",
+	})
+
+	policy, err := readPolicy(Options{RepoRoot: root})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if readOnly := findReadOnlyOrgs(policy, []string{"org-a", "org-retired"}); !reflect.DeepEqual(readOnly, []string{"org-retired"}) {
+		t.Errorf("unexpected read-only orgs: %v", readOnly)
+	}
+
+	if _, err := readPolicy(Options{RepoRoot: root, PolicyFile: filepath.Join(root, "bad-policy.yaml")}); err == nil {
+		t.Errorf("expected error for unknown policy fields")
+	}
+
+	typoPath := filepath.Join(root, "typo-policy.yaml")
+	expected := fmt.Sprintf("invalid policy:\n%[1]s:3: unknown org org-retird\n%[1]s:5: unknown org org-b", typoPath)
+	if _, err := readPolicy(Options{RepoRoot: root, PolicyFile: typoPath}); err == nil || err.Error() != expected {
+		t.Errorf("expected error %q for unknown orgs, got %v", expected, err)
+	}
+
+	if _, err := readPolicy(Options{RepoRoot: t.TempDir()}); err != nil {
+		t.Errorf("unexpected error for a missing default policy file: %v", err)
+	}
+	if _, err := readPolicy(Options{RepoRoot: root, PolicyFile: filepath.Join(root, "missing.yaml")}); err == nil {
+		t.Errorf("expected error for a missing policy file")
+	}
+}
+
+func TestAuditBars(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/etcd-io/org.yaml": "name: etcd\n",
+		"config/policy.yaml": `orgs:
+  etcd-io:
+    audit:
+      activityThreshold: 1
+      period: q
+      teams:
+        maintainers:
+          activityThreshold: 0
+        reviewers:
+          period: m
+  negative:
+    audit:
+      teams:
+        foo:
+          activityThreshold: -1
+`,
+	})
+
+	if _, err := readPolicy(Options{RepoRoot: root}); err == nil {
+		t.Fatalf("expected error for a negative threshold")
+	}
+
+	if err := os.WriteFile(filepath.Join(root, defaultPolicyPath), []byte(`orgs:
+  etcd-io:
+    audit:
+      activityThreshold: 1
+      period: q
+      teams:
+        maintainers:
+          activityThreshold: 0
+        reviewers:
+          period: m
+`), 0644); err != nil {
+		t.Fatalf("writing policy: %v", err)
+	}
+
+	o := Options{RepoRoot: root, AuditOptions: AuditOptions{ActivityThreshold: 10, Period: "y"}}
+	policy, err := readPolicy(o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		org      string
+		teams    []string
+		expected []activityBar
+	}{
+		{
+			org:      "kubernetes",
+			teams:    []string{"maintainers"},
+			expected: []activityBar{{threshold: 10, period: "y"}},
+		},
+		{
+			org:      "etcd-io",
+			teams:    []string{"other"},
+			expected: []activityBar{{threshold: 1, period: "q"}},
+		},
+		{
+			org:   "etcd-io",
+			teams: []string{"maintainers", "reviewers"},
+			expected: []activityBar{
+				{threshold: 0, period: "q"},
+				{threshold: 1, period: "m"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		if bars := policy.auditBars(o, c.org, c.teams); !reflect.DeepEqual(bars, c.expected) {
+			t.Errorf("unexpected bars for %s teams %v: %v, expected %v", c.org, c.teams, bars, c.expected)
+		}
+	}
+}
@@ -18,63 +18,164 @@ package main
 
 import (
 	"fmt"
-	"path/filepath"
+	"sort"
 	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
 )
 
-func RemoveMemberFromOrgs(o Options, username string) error {
-	if invalidOrgs := findInvalidOrgs(o.Orgs); len(invalidOrgs) > 0 {
-		return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+// teamsWithUser returns the names leading to every team in teams, including
+// children, that has username as a member or maintainer.
+func teamsWithUser(teams map[string]org.Team, username string) [][]string {
+	found := [][]string{}
+	for name, team := range teams {
+		if stringInSliceCaseAgnostic(team.Members, username) || stringInSliceCaseAgnostic(team.Maintainers, username) {
+			found = append(found, []string{name})
+		}
+
+		for _, names := range teamsWithUser(team.Children, username) {
+			found = append(found, append([]string{name}, names...))
+		}
 	}
 
-	if !o.Confirm {
-		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	sort.Slice(found, func(i, j int) bool {
+		return strings.Join(found[i], "/") < strings.Join(found[j], "/")
+	})
+	return found
+}
+
+// removeMembersFromOrg removes usernames from the members of orgName and from
+// every team of the org, recording the changes without saving them. Admins
+// are skipped. Users who aren't org members are still removed from teams
+// listing them, and skipped if there are none.
+func removeMembersFromOrg(orgName string, usernames []string, changes *changeSet, results *summary) error {
+	files, err := orgConfigFiles(changes.repoRoot, orgName)
+	if err != nil {
+		return err
 	}
 
-	configsModified := []string{}
-	for _, org := range o.Orgs {
-		fmt.Printf("removing %s from %s org\n", username, org)
+	memberFiles, err := orgMemberFiles(changes.repoRoot, orgName)
+	if err != nil {
+		return err
+	}
 
-		relativeConfigPath := fmt.Sprintf(orgConfigPathFormat, org)
-		configPath := filepath.Join(o.RepoRoot, relativeConfigPath)
+	toRemove := []string{}
+	notMembers := map[string]bool{}
+	for _, username := range usernames {
+		fmt.Printf("removing %s from %s org\n", username, orgName)
 
-		config, err := readConfig(configPath)
+		role, source, err := orgRole(changes, orgName, username)
 		if err != nil {
-			return fmt.Errorf("reading config: %s", err)
+			return err
 		}
-
-		if stringInSliceCaseAgnostic(config.Admins, username) {
-			return fmt.Errorf("user %s is an admin for org %s", username, org)
+		if role == "admins" {
+			results.skip(username, fmt.Sprintf("is an admin for org %s (%s)", orgName, source))
+			continue
 		}
 
-		if !stringInSliceCaseAgnostic(config.Members, username) {
-			return fmt.Errorf("user %s doesn't exist in org %s", username, org)
+		if role == "" {
+			notMembers[username] = true
+			toRemove = append(toRemove, username)
+			continue
 		}
 
-		// remove user from the org
-		for i, member := range config.Members {
-			if strings.EqualFold(username, member) {
-				config.Members = append(config.Members[:i], config.Members[i+1:]...)
-				break
+		// remove user from the org, wherever it is listed
+		for _, path := range memberFiles {
+			file, err := changes.file(path)
+			if err != nil {
+				return fmt.Errorf("reading config: %s", err)
+			}
+
+			removed, err := file.removeFromList(username, "members")
+			if err != nil {
+				return fmt.Errorf("removing %s from %s org: %s", username, orgName, err)
+			}
+			if removed {
+				changes.markModified(path)
 			}
 		}
 
-		if o.Confirm {
-			fmt.Printf("saving config for %s org\n", org)
-			if err := saveConfig(configPath, config); err != nil {
-				return fmt.Errorf("saving config: %s", err)
+		results.changed(username, orgName)
+		toRemove = append(toRemove, username)
+	}
+
+	if len(toRemove) == 0 {
+		return nil
+	}
+
+	// remove users from all teams defined in the org
+	inTeams := map[string]bool{}
+	for _, relativeConfigPath := range files {
+		file, err := changes.file(relativeConfigPath)
+		if err != nil {
+			return fmt.Errorf("reading config: %s", err)
+		}
+
+		config, err := file.decode()
+		if err != nil {
+			return fmt.Errorf("reading config: %s", err)
+		}
+
+		for _, username := range toRemove {
+			for _, names := range teamsWithUser(config.Teams, username) {
+				team := strings.Join(names, "/")
+				fmt.Printf("removing %s from %s team in %s\n", username, team, relativeConfigPath)
+				for _, field := range []string{"members", "maintainers"} {
+					if _, err := file.removeFromList(username, teamKeys(names, field)...); err != nil {
+						return fmt.Errorf("removing %s from %s team: %s", username, team, err)
+					}
+				}
+
+				changes.markModified(relativeConfigPath)
+				results.changed(username, fmt.Sprintf("%s/%s", orgName, team))
+				inTeams[username] = true
 			}
 		}
+	}
+
+	for _, username := range toRemove {
+		if notMembers[username] && !inTeams[username] {
+			results.skip(username, fmt.Sprintf("doesn't exist in org %s", orgName))
+		}
+	}
+
+	return nil
+}
+
+func RemoveMembersFromOrgs(o Options, usernames []string) error {
+	if err := validateOrgs(o.RepoRoot, o.Orgs); err != nil {
+		return err
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+
+	changes := newChangeSet(o.RepoRoot)
+	results := newSummary(usernames)
+	for _, orgName := range o.Orgs {
+		if err := removeMembersFromOrg(orgName, usernames, changes, results); err != nil {
+			return err
+		}
+	}
+
+	results.print("removed")
 
-		configsModified = append(configsModified, relativeConfigPath)
-		fmt.Printf("config files modified: %s\n", strings.Join(configsModified, ", "))
+	if len(changes.modified) == 0 {
+		fmt.Println("nothing to change")
+		return nil
 	}
+	fmt.Printf("config files modified: %s\n", strings.Join(changes.modified, ", "))
 
 	if o.Confirm {
+		if err := changes.save(); err != nil {
+			return fmt.Errorf("saving config: %s", err)
+		}
+
 		fmt.Println("committing changes")
 
-		message := fmt.Sprintf("remove %s from %s", username, strings.Join(o.Orgs, ", "))
-		if err := commitChanges(o.RepoRoot, configsModified, message); err != nil {
+		message := results.commitMessage("remove", "from", o.Orgs)
+		if err := commitChanges(o.RepoRoot, changes.modified, message); err != nil {
 			return fmt.Errorf("committing changes: %s", err)
 		}
 	}
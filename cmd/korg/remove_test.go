@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestRemoveMembersFromOrg(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/kubernetes/org.yaml":               "admins:\n- alice\nmembers:\n- bob\n- carol\nteams:\n  top:\n    members:\n    - dave\n    privacy: closed\n",
+		"config/kubernetes/members.d/sig-foo.yaml": "admins:\n- erin\nmembers:\n- Bob\n- dave\n",
+	})
+	changes := newChangeSet(root)
+	results := newSummary([]string{"alice", "bob", "dave", "erin", "frank"})
+
+	if err := removeMembersFromOrg("kubernetes", []string{"alice", "bob", "dave", "erin", "frank"}, changes, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := changes.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"config/kubernetes/org.yaml":               "admins:\n- alice\nmembers:\n- carol\nteams:\n  top:\n    privacy: closed\n",
+		"config/kubernetes/members.d/sig-foo.yaml": "admins:\n- erin\n",
+	}
+	for path, contents := range expected {
+		got, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != contents {
+			t.Errorf("unexpected contents of %s:\n%s\nexpected:\n%s", path, got, contents)
+		}
+	}
+	if users := results.changedUsers(); !reflect.DeepEqual(users, []string{"bob", "dave"}) {
+		t.Errorf("unexpected changed users: %v", users)
+	}
+}
+
+func TestRemoveMembersFromOrgTeams(t *testing.T) {
+	const orgConfig = `admins:
+- alice
+members:
+- bob
+- carol
+teams:
+  top:
+    maintainers:
+    - bob
+    members:
+    - carol
+    privacy: closed
+`
+	const teamsConfig = `teams:
+  parent:
+    members:
+    - bob
+    - dave
+    privacy: closed
+    teams:
+      child:
+        maintainers:
+        - bob
+        members:
+        - carol
+        - dave
+        privacy: closed
+`
+
+	cases := []struct {
+		desc      string
+		usernames []string
+		orgConfig string
+		teams     string
+		changes   map[string][]string
+		skipped   map[string][]string
+	}{
+		{
+			desc:      "maintainer of a team and a child team",
+			usernames: []string{"bob"},
+			orgConfig: `admins:
+- alice
+members:
+- carol
+teams:
+  top:
+    members:
+    - carol
+    privacy: closed
+`,
+			teams: `teams:
+  parent:
+    members:
+    - dave
+    privacy: closed
+    teams:
+      child:
+        members:
+        - carol
+        - dave
+        privacy: closed
+`,
+			changes: map[string][]string{"bob": {"kubernetes", "kubernetes/parent", "kubernetes/parent/child", "kubernetes/top"}},
+		},
+		{
+			desc:      "member of a child team",
+			usernames: []string{"carol"},
+			orgConfig: `admins:
+- alice
+members:
+- bob
+teams:
+  top:
+    maintainers:
+    - bob
+    privacy: closed
+`,
+			teams:   strings.Replace(teamsConfig, "        - carol\n", "", 1),
+			changes: map[string][]string{"carol": {"kubernetes", "kubernetes/parent/child", "kubernetes/top"}},
+		},
+		{
+			desc:      "team entries of a user who isn't an org member",
+			usernames: []string{"dave"},
+			orgConfig: orgConfig,
+			teams:     strings.Replace(strings.Replace(teamsConfig, "    - dave\n", "", 1), "        - dave\n", "", 1),
+			changes:   map[string][]string{"dave": {"kubernetes/parent", "kubernetes/parent/child"}},
+		},
+		{
+			desc:      "user in neither the org nor its teams",
+			usernames: []string{"erin"},
+			orgConfig: orgConfig,
+			teams:     teamsConfig,
+			skipped:   map[string][]string{"erin": {"doesn't exist in org kubernetes"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			root := testutil.WriteFiles(t, map[string]string{
+				"config/kubernetes/org.yaml":           orgConfig,
+				"config/kubernetes/sig-foo/teams.yaml": teamsConfig,
+			})
+			changes := newChangeSet(root)
+			results := newSummary(tc.usernames)
+
+			if err := removeMembersFromOrg("kubernetes", tc.usernames, changes, results); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := changes.save(); err != nil {
+				t.Fatal(err)
+			}
+
+			for path, expected := range map[string]string{
+				"config/kubernetes/org.yaml":           tc.orgConfig,
+				"config/kubernetes/sig-foo/teams.yaml": tc.teams,
+			} {
+				got, err := os.ReadFile(filepath.Join(root, path))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != expected {
+					t.Errorf("unexpected contents of %s:\n%s\nexpected:\n%s", path, got, expected)
+				}
+			}
+
+			expectedChanges := tc.changes
+			if expectedChanges == nil {
+				expectedChanges = map[string][]string{}
+			}
+			for username, targets := range results.changes {
+				sort.Strings(targets)
+				results.changes[username] = targets
+			}
+			if !reflect.DeepEqual(results.changes, expectedChanges) {
+				t.Errorf("unexpected changes %v, expected %v", results.changes, expectedChanges)
+			}
+			expectedSkipped := tc.skipped
+			if expectedSkipped == nil {
+				expectedSkipped = map[string][]string{}
+			}
+			if !reflect.DeepEqual(results.skipped, expectedSkipped) {
+				t.Errorf("unexpected skips %v, expected %v", results.skipped, expectedSkipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+	formatYAML     = "yaml"
+	formatCSV      = "csv"
+	formatText     = "text"
+)
+
+var reportFormats = []string{formatMarkdown, formatJSON, formatYAML, formatCSV}
+
+// writeReport writes the audited members to w in the format asked for by o.
+// Every format but markdown emits every field of UserInfo regardless of the
+// checks enabled, so that consumers can rely on a stable schema.
+func writeReport(w io.Writer, o Options, members []UserInfo) error {
+	members = normalizeReport(members)
+
+	switch o.Format {
+	case formatJSON:
+		b, err := json.MarshalIndent(members, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal report: %s", err)
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case formatYAML:
+		b, err := yaml.Marshal(members)
+		if err != nil {
+			return fmt.Errorf("unable to marshal report: %s", err)
+		}
+		_, err = w.Write(b)
+		return err
+	case formatCSV:
+		return writeCSVReport(w, members)
+	case formatMarkdown, "":
+		writeMarkdownReport(w, o, members)
+		return nil
+	default:
+		return fmt.Errorf("unknown report format %q", o.Format)
+	}
+}
+
+// normalizeReport makes empty fields explicit and sorts members, their orgs
+// and teams so that identical audits produce identical reports.
+func normalizeReport(members []UserInfo) []UserInfo {
+	out := make([]UserInfo, 0, len(members))
+	for _, m := range members {
+		m.Orgs = append([]string{}, m.Orgs...)
+		sort.Strings(m.Orgs)
+
+		teams := map[string][]string{}
+		for org, t := range m.Teams {
+			teams[org] = append([]string{}, t...)
+			sort.Strings(teams[org])
+		}
+		m.Teams = teams
+
+		m.Owners = append([]Ownership{}, m.Owners...)
+		sortOwnerships(m.Owners)
+
+		out = append(out, m)
+	}
+
+	// members are audited per org, so group them by org
+	sort.Slice(out, func(i, j int) bool {
+		oi, oj := strings.Join(out[i].Orgs, ","), strings.Join(out[j].Orgs, ",")
+		if oi != oj {
+			return oi < oj
+		}
+		return out[i].Username < out[j].Username
+	})
+	return out
+}
+
+// teamList flattens the teams of a member into org/team entries.
+func teamList(teams map[string][]string) []string {
+	list := []string{}
+	for org, t := range teams {
+		for _, team := range t {
+			list = append(list, fmt.Sprintf("%s/%s", org, team))
+		}
+	}
+
+	sort.Strings(list)
+	return list
+}
+
+// ownerStatus reports whether a member is an owner, or unknown if the lookup
+// failed.
+func ownerStatus(m UserInfo) string {
+	if m.OwnerLookupError != "" {
+		return "unknown"
+	}
+
+	return strconv.FormatBool(m.IsOwner)
+}
+
+// ownerList formats the ownerships of a member as path:role[:filter] entries.
+func ownerList(ownerships []Ownership) []string {
+	list := []string{}
+	for _, o := range ownerships {
+		entry := o.Path
+		if o.Role != "" || o.Filter != "" {
+			entry = fmt.Sprintf("%s:%s", entry, o.Role)
+		}
+		if o.Filter != "" {
+			entry = fmt.Sprintf("%s:%s", entry, o.Filter)
+		}
+		list = append(list, entry)
+	}
+
+	return list
+}
+
+func writeCSVReport(w io.Writer, members []UserInfo) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"username", "orgs", "teams", "contributions", "activityThreshold", "period", "isOwner", "owners", "exceptionReason"}); err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		record := []string{
+			m.Username,
+			strings.Join(m.Orgs, ";"),
+			strings.Join(teamList(m.Teams), ";"),
+			strconv.Itoa(m.Contributions),
+			strconv.Itoa(m.ActivityThreshold),
+			m.Period,
+			ownerStatus(m),
+			strings.Join(ownerList(m.Owners), ";"),
+			m.ExceptionReason,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeMarkdownReport writes a section per org, holding a table of the members
+// audited in the org.
+func writeMarkdownReport(w io.Writer, o Options, members []UserInfo) {
+	for i := 0; i < len(members); {
+		orgs := strings.Join(members[i].Orgs, ", ")
+		j := i
+		sameBar := true
+		for j < len(members) && strings.Join(members[j].Orgs, ", ") == orgs {
+			if members[j].ActivityThreshold != members[i].ActivityThreshold || members[j].Period != members[i].Period {
+				sameBar = false
+			}
+			j++
+		}
+
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## %s\n\n", orgs)
+		// members of teams with their own rule may be held to another bar
+		if sameBar {
+			fmt.Fprintf(w, "Members with %d or fewer contributions over the %q period.\n\n", members[i].ActivityThreshold, members[i].Period)
+		}
+		writeMarkdownTable(w, o, members[i:j])
+		i = j
+	}
+}
+
+func writeMarkdownTable(w io.Writer, o Options, members []UserInfo) {
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+
+	headers := []string{"Username", "Orgs"}
+	if o.CheckTeams {
+		headers = append(headers, "Teams")
+	}
+	if o.CheckOwners {
+		if o.OwnersDir != "" {
+			headers = append(headers, "Owner", "Owners Files")
+		} else {
+			headers = append(headers, "Owner", "Owners Link")
+		}
+	}
+	if o.ExceptionsFile != "" {
+		headers = append(headers, "Exception")
+	}
+	table.SetHeader(headers)
+
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+
+	for _, v := range members {
+		row := []string{
+			v.Username,
+			strings.Join(v.Orgs, ", "),
+		}
+
+		if o.CheckTeams {
+			orgs := []string{}
+			for org := range v.Teams {
+				orgs = append(orgs, org)
+			}
+			sort.Strings(orgs)
+
+			teams := []string{}
+			for _, org := range orgs {
+				teams = append(teams, fmt.Sprintf("%s: %s", org, strings.Join(v.Teams[org], ", ")))
+			}
+			row = append(row, strings.Join(teams, "; "))
+		}
+
+		if o.CheckOwners {
+			switch {
+			case v.OwnerLookupError != "":
+				row = append(row, "unknown", "")
+			case v.IsOwner && o.OwnersDir != "":
+				files := []string{}
+				for _, ownership := range v.Owners {
+					files = append(files, ownership.String())
+				}
+				row = append(row, "yes", strings.Join(files, ", "))
+			case v.IsOwner:
+				row = append(row, "yes", fmt.Sprintf("https://go.k8s.io/owners/%s", v.Username))
+			default:
+				row = append(row, "no", "")
+			}
+		}
+
+		if o.ExceptionsFile != "" {
+			row = append(row, v.ExceptionReason)
+		}
+
+		table.Append(row)
+	}
+	table.Render()
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+var testReportMembers = []UserInfo{
+	{
+		Username:          "zed",
+		Contributions:     3,
+		ActivityThreshold: 5,
+		Period:            "y",
+		Orgs:              []string{"kubernetes-sigs", "kubernetes"},
+		Teams:             map[string][]string{"kubernetes": {"team-b", "team-a"}},
+		IsOwner:           true,
+		Owners: []Ownership{
+			{Path: "kubernetes/pkg/OWNERS", Role: roleReviewer, Filter: "\\.go$"},
+			{Path: "kubernetes/OWNERS", Role: roleApprover},
+		},
+	},
+	{
+		Username:         "k8s-ci-robot",
+		Orgs:             []string{"kubernetes"},
+		OwnerLookupError: "timeout",
+		ExceptionReason:  "bot account",
+	},
+}
+
+func TestWriteReport(t *testing.T) {
+	cases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: formatJSON,
+			expected: `[
+  {
+    "username": "k8s-ci-robot",
+    "contributions": 0,
+    "orgs": [
+      "kubernetes"
+    ],
+    "teams": {},
+    "isOwner": false,
+    "activityThreshold": 0,
+    "period": "",
+    "owners": [],
+    "ownerLookupError": "timeout",
+    "exceptionReason": "bot account"
+  },
+  {
+    "username": "zed",
+    "contributions": 3,
+    "orgs": [
+      "kubernetes",
+      "kubernetes-sigs"
+    ],
+    "teams": {
+      "kubernetes": [
+        "team-a",
+        "team-b"
+      ]
+    },
+    "isOwner": true,
+    "activityThreshold": 5,
+    "period": "y",
+    "owners": [
+      {
+        "path": "kubernetes/OWNERS",
+        "role": "approver"
+      },
+      {
+        "path": "kubernetes/pkg/OWNERS",
+        "role": "reviewer",
+        "filter": "\\.go$"
+      }
+    ],
+    "ownerLookupError": "",
+    "exceptionReason": ""
+  }
+]
+`,
+		},
+		{
+			format: formatYAML,
+			expected: `- activityThreshold: 0
+  contributions: 0
+  exceptionReason: bot account
+  isOwner: false
+  orgs:
+  - kubernetes
+  ownerLookupError: timeout
+  owners: []
+  period: ""
+  teams: {}
+  username: k8s-ci-robot
+- activityThreshold: 5
+  contributions: 3
+  exceptionReason: ""
+  isOwner: true
+  orgs:
+  - kubernetes
+  - kubernetes-sigs
+  ownerLookupError: ""
+  owners:
+  - path: kubernetes/OWNERS
+    role: approver
+  - filter: \.go$
+    path: kubernetes/pkg/OWNERS
+    role: reviewer
+  period: "y"
+  teams:
+    kubernetes:
+    - team-a
+    - team-b
+  username: zed
+`,
+		},
+		{
+			format: formatCSV,
+			expected: `username,orgs,teams,contributions,activityThreshold,period,isOwner,owners,exceptionReason
+k8s-ci-robot,kubernetes,,0,0,,unknown,,bot account
+zed,kubernetes;kubernetes-sigs,kubernetes/team-a;kubernetes/team-b,3,5,y,true,kubernetes/OWNERS:approver;kubernetes/pkg/OWNERS:reviewer:\.go$,
+`,
+		},
+	}
+
+	for _, c := range cases {
+		var b bytes.Buffer
+		if err := writeReport(&b, Options{AuditOptions: AuditOptions{Format: c.format}}, testReportMembers); err != nil {
+			t.Errorf("unexpected error writing %s report: %v", c.format, err)
+			continue
+		}
+		if b.String() != c.expected {
+			t.Errorf("unexpected %s report:\n%s\nexpected:\n%s", c.format, b.String(), c.expected)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const (
+	statusActive   = "active"
+	statusInactive = "inactive"
+	// statusExcepted members are below the activity bar but kept by an
+	// exception
+	statusExcepted = "excepted"
+)
+
+// Snapshot records how every member was classified by an audit, so that
+// audits can be compared over time.
+type Snapshot struct {
+	Time time.Time `json:"time"`
+	// Orgs are the orgs the audit covered.
+	Orgs    []string         `json:"orgs,omitempty"`
+	Members []SnapshotMember `json:"members"`
+}
+
+// SnapshotMember is the classification of a member within an org.
+type SnapshotMember struct {
+	Username          string `json:"username"`
+	Org               string `json:"org"`
+	Contributions     int    `json:"contributions"`
+	ActivityThreshold int    `json:"activityThreshold"`
+	Period            string `json:"period"`
+	Status            string `json:"status"`
+}
+
+func (m SnapshotMember) key() string {
+	return m.Org + "/" + github.NormLogin(m.Username)
+}
+
+// describe returns the contributions and status of m, empty if m is nil.
+func (m *SnapshotMember) describe() (string, string) {
+	if m == nil {
+		return "", ""
+	}
+	return strconv.Itoa(m.Contributions), m.Status
+}
+
+func newSnapshot(now time.Time, orgs []string) *Snapshot {
+	return &Snapshot{Time: now.UTC(), Orgs: orgs, Members: []SnapshotMember{}}
+}
+
+// audited returns whether the audit covered orgName. Snapshots predating
+// Orgs covered the orgs of their members.
+func (s *Snapshot) audited(orgName string) bool {
+	if len(s.Orgs) > 0 {
+		return stringInSlice(s.Orgs, orgName)
+	}
+	for _, m := range s.Members {
+		if m.Org == orgName {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Snapshot) add(member UserInfo, orgName, status string) {
+	s.Members = append(s.Members, SnapshotMember{
+		Username:          member.Username,
+		Org:               orgName,
+		Contributions:     member.Contributions,
+		ActivityThreshold: member.ActivityThreshold,
+		Period:            member.Period,
+		Status:            status,
+	})
+}
+
+func (s *Snapshot) write(path string) error {
+	sort.Slice(s.Members, func(i, j int) bool {
+		if s.Members[i].Org != s.Members[j].Org {
+			return s.Members[i].Org < s.Members[j].Org
+		}
+		return s.Members[i].Username < s.Members[j].Username
+	})
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal snapshot: %s", err)
+	}
+
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("unable to write snapshot to %s: %s", path, err)
+	}
+	return nil
+}
+
+func readSnapshot(path string) (*Snapshot, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	d := json.NewDecoder(bytes.NewReader(contents))
+	d.DisallowUnknownFields()
+	var s Snapshot
+	if err := d.Decode(&s); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal snapshot from %s: %s", path, err)
+	}
+
+	return &s, nil
+}
+
+// SnapshotChange is how a member changed between two audits. Old or New is
+// nil if the member wasn't in the org at the time.
+type SnapshotChange struct {
+	Username string          `json:"username"`
+	Org      string          `json:"org"`
+	Old      *SnapshotMember `json:"old"`
+	New      *SnapshotMember `json:"new"`
+}
+
+// SnapshotDiff groups the members whose classification matters between two
+// audits.
+type SnapshotDiff struct {
+	// NewlyInactive members are inactive but weren't in the old audit, or
+	// were active or kept by an exception.
+	NewlyInactive []SnapshotChange `json:"newlyInactive"`
+	// StillInactive members were inactive in both audits, i.e. their grace
+	// period is over.
+	StillInactive []SnapshotChange `json:"stillInactive"`
+	// Recovered members were inactive in the old audit and are active now.
+	Recovered []SnapshotChange `json:"recovered"`
+	// Removed members were audited in an org they are no longer part of.
+	// Orgs the new audit didn't cover are left out.
+	Removed []SnapshotChange `json:"removed"`
+}
+
+// diffSnapshots compares the members of two audits, matching them by org and
+// normalized username.
+func diffSnapshots(before, after *Snapshot) SnapshotDiff {
+	diff := SnapshotDiff{
+		NewlyInactive: []SnapshotChange{},
+		StillInactive: []SnapshotChange{},
+		Recovered:     []SnapshotChange{},
+		Removed:       []SnapshotChange{},
+	}
+
+	oldMembers := map[string]*SnapshotMember{}
+	for i := range before.Members {
+		oldMembers[before.Members[i].key()] = &before.Members[i]
+	}
+	newMembers := map[string]*SnapshotMember{}
+	for i := range after.Members {
+		newMembers[after.Members[i].key()] = &after.Members[i]
+	}
+
+	for key, n := range newMembers {
+		o := oldMembers[key]
+		change := SnapshotChange{Username: n.Username, Org: n.Org, Old: o, New: n}
+		switch {
+		case n.Status == statusInactive && o != nil && o.Status == statusInactive:
+			diff.StillInactive = append(diff.StillInactive, change)
+		case n.Status == statusInactive:
+			diff.NewlyInactive = append(diff.NewlyInactive, change)
+		case n.Status == statusActive && o != nil && o.Status == statusInactive:
+			diff.Recovered = append(diff.Recovered, change)
+		}
+	}
+
+	for key, o := range oldMembers {
+		if _, found := newMembers[key]; !found && after.audited(o.Org) {
+			diff.Removed = append(diff.Removed, SnapshotChange{Username: o.Username, Org: o.Org, Old: o})
+		}
+	}
+
+	for _, changes := range [][]SnapshotChange{diff.NewlyInactive, diff.StillInactive, diff.Recovered, diff.Removed} {
+		sort.Slice(changes, func(i, j int) bool {
+			if changes[i].Org != changes[j].Org {
+				return changes[i].Org < changes[j].Org
+			}
+			return changes[i].Username < changes[j].Username
+		})
+	}
+
+	return diff
+}
+
+// DiffAudits compares the snapshots of two audits.
+func DiffAudits(w io.Writer, oldPath, newPath, format string) error {
+	before, err := readSnapshot(oldPath)
+	if err != nil {
+		return err
+	}
+	after, err := readSnapshot(newPath)
+	if err != nil {
+		return err
+	}
+
+	diff := diffSnapshots(before, after)
+	switch format {
+	case formatJSON:
+		b, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal diff: %s", err)
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case formatMarkdown, "":
+		fmt.Fprintf(w, "Changes between the audits of %s and %s.\n", before.Time.Format(time.RFC3339), after.Time.Format(time.RFC3339))
+		writeDiffSection(w, "Newly inactive", diff.NewlyInactive)
+		writeDiffSection(w, "Still inactive", diff.StillInactive)
+		writeDiffSection(w, "Recovered", diff.Recovered)
+		writeDiffSection(w, "Removed", diff.Removed)
+		return nil
+	default:
+		return fmt.Errorf("unknown diff format %q", format)
+	}
+}
+
+func writeDiffSection(w io.Writer, title string, changes []SnapshotChange) {
+	fmt.Fprintf(w, "\n## %s (%d)\n\n", title, len(changes))
+	if len(changes) == 0 {
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Username", "Org", "Old Contributions", "New Contributions", "Old Status", "New Status"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+
+	for _, c := range changes {
+		oldContributions, oldStatus := c.Old.describe()
+		newContributions, newStatus := c.New.describe()
+		table.Append([]string{c.Username, c.Org, oldContributions, newContributions, oldStatus, newStatus})
+	}
+	table.Render()
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	before := &Snapshot{Members: []SnapshotMember{
+		{Username: "alice", Org: "kubernetes", Contributions: 0, Status: statusInactive},
+		{Username: "Bob", Org: "kubernetes", Contributions: 1, Status: statusInactive},
+		{Username: "carol", Org: "kubernetes", Contributions: 20, Status: statusActive},
+		{Username: "dave", Org: "kubernetes", Contributions: 0, Status: statusInactive},
+		{Username: "erin", Org: "kubernetes", Contributions: 0, Status: statusExcepted},
+		{Username: "alice", Org: "etcd-io", Contributions: 0, Status: statusActive},
+	}}
+	after := &Snapshot{Members: []SnapshotMember{
+		{Username: "alice", Org: "kubernetes", Contributions: 0, Status: statusInactive},
+		{Username: "bob", Org: "kubernetes", Contributions: 12, Status: statusActive},
+		{Username: "carol", Org: "kubernetes", Contributions: 2, Status: statusInactive},
+		{Username: "erin", Org: "kubernetes", Contributions: 0, Status: statusInactive},
+		{Username: "frank", Org: "kubernetes", Contributions: 0, Status: statusInactive},
+		{Username: "alice", Org: "etcd-io", Contributions: 0, Status: statusInactive},
+	}}
+
+	diff := diffSnapshots(before, after)
+	names := func(changes []SnapshotChange) []string {
+		list := []string{}
+		for _, c := range changes {
+			list = append(list, c.Org+"/"+c.Username)
+		}
+		return list
+	}
+
+	cases := []struct {
+		desc     string
+		got      []SnapshotChange
+		expected []string
+	}{
+		{desc: "newly inactive", got: diff.NewlyInactive, expected: []string{"etcd-io/alice", "kubernetes/carol", "kubernetes/erin", "kubernetes/frank"}},
+		{desc: "still inactive", got: diff.StillInactive, expected: []string{"kubernetes/alice"}},
+		{desc: "recovered", got: diff.Recovered, expected: []string{"kubernetes/bob"}},
+		{desc: "removed", got: diff.Removed, expected: []string{"kubernetes/dave"}},
+	}
+	for _, c := range cases {
+		if got := names(c.got); !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("unexpected %s members: %v, expected %v", c.desc, got, c.expected)
+		}
+	}
+
+	if diff.Recovered[0].Old.Contributions != 1 || diff.Recovered[0].New.Contributions != 12 {
+		t.Errorf("unexpected contributions for a recovered member: %+v %+v", diff.Recovered[0].Old, diff.Recovered[0].New)
+	}
+}
+
+func TestDiffSnapshotsOrgs(t *testing.T) {
+	before := &Snapshot{Orgs: []string{"etcd-io", "kubernetes"}, Members: []SnapshotMember{
+		{Username: "alice", Org: "kubernetes", Status: statusActive},
+		{Username: "bob", Org: "kubernetes", Status: statusActive},
+		{Username: "carol", Org: "etcd-io", Status: statusActive},
+	}}
+
+	cases := []struct {
+		desc     string
+		after    *Snapshot
+		expected []string
+	}{
+		{
+			desc: "an org left out of the new audit",
+			after: &Snapshot{Orgs: []string{"kubernetes", "kubernetes-sigs"}, Members: []SnapshotMember{
+				{Username: "alice", Org: "kubernetes", Status: statusActive},
+				{Username: "dave", Org: "kubernetes-sigs", Status: statusActive},
+			}},
+			expected: []string{"kubernetes/bob"},
+		},
+		{
+			desc: "an audited org left without members",
+			after: &Snapshot{Orgs: []string{"etcd-io", "kubernetes"}, Members: []SnapshotMember{
+				{Username: "alice", Org: "kubernetes", Status: statusActive},
+				{Username: "bob", Org: "kubernetes", Status: statusActive},
+			}},
+			expected: []string{"etcd-io/carol"},
+		},
+		{
+			desc: "a snapshot without orgs",
+			after: &Snapshot{Members: []SnapshotMember{
+				{Username: "alice", Org: "kubernetes", Status: statusActive},
+			}},
+			expected: []string{"kubernetes/bob"},
+		},
+	}
+
+	for _, c := range cases {
+		removed := []string{}
+		for _, change := range diffSnapshots(before, c.after).Removed {
+			removed = append(removed, change.Org+"/"+change.Username)
+		}
+		if !reflect.DeepEqual(removed, c.expected) {
+			t.Errorf("%s: unexpected removed members %v, expected %v", c.desc, removed, c.expected)
+		}
+	}
+}
+
+func TestDiffAudits(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")}
+	snapshots := []*Snapshot{
+		newSnapshot(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), []string{"kubernetes"}),
+		newSnapshot(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), []string{"kubernetes"}),
+	}
+	snapshots[0].add(UserInfo{Username: "alice", Contributions: 1}, "kubernetes", statusInactive)
+	snapshots[1].add(UserInfo{Username: "alice", Contributions: 4}, "kubernetes", statusActive)
+	for i, s := range snapshots {
+		if err := s.write(paths[i]); err != nil {
+			t.Fatalf("writing snapshot: %v", err)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := DiffAudits(&b, paths[0], paths[1], formatMarkdown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"Changes between the audits of 2024-03-01T00:00:00Z and 2024-06-01T00:00:00Z.",
+		"## Newly inactive (0)",
+		"## Recovered (1)",
+		"| alice    | kubernetes |                 1 |                 4 | inactive   | active     |",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected diff to contain %q, got:\n%s", expected, b.String())
+		}
+	}
+
+	if err := DiffAudits(&b, paths[0], filepath.Join(dir, "missing.json"), formatJSON); err == nil {
+		t.Errorf("expected error for a missing snapshot")
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// teamLocation points at a team definition within the config tree.
+type teamLocation struct {
+	// path of the file defining the team, relative to the repo root
+	path string
+	// names leading to the team in the file, starting from a top-level
+	// team and descending through its children
+	names []string
+	// line of the file the team is defined at
+	line int
+}
+
+func (l teamLocation) String() string {
+	return fmt.Sprintf("%s (%s:%d)", strings.Join(l.names, "/"), l.path, l.line)
+}
+
+// orgConfigFiles lists the org.yaml of the org followed by every teams.yaml
+// below it, at any depth, then its fragments, relative to the repo root. It
+// follows the same layout LoadOrgs merges.
+func orgConfigFiles(repoRoot, orgName string) ([]string, error) {
+	orgPath := filepath.Join(repoRoot, fmt.Sprintf(orgConfigPathFormat, orgName))
+	teams, err := orgconfig.TeamsFiles(orgPath)
+	if err != nil {
+		return nil, err
+	}
+	fragments, err := orgconfig.FragmentFiles(orgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return relativeConfigFiles(repoRoot, orgName, append(teams, fragments...))
+}
+
+// orgMemberFiles lists the org.yaml of the org followed by the members.d
+// fragments below it, i.e. the files listing its admins and members, relative
+// to the repo root.
+func orgMemberFiles(repoRoot, orgName string) ([]string, error) {
+	fragments, err := orgconfig.FragmentFiles(filepath.Join(repoRoot, fmt.Sprintf(orgConfigPathFormat, orgName)))
+	if err != nil {
+		return nil, err
+	}
+
+	members := []string{}
+	for _, p := range fragments {
+		if filepath.Base(p) != orgconfig.ReposFile {
+			members = append(members, p)
+		}
+	}
+	return relativeConfigFiles(repoRoot, orgName, members)
+}
+
+// relativeConfigFiles lists the org.yaml of the org followed by paths, made
+// relative to the repo root.
+func relativeConfigFiles(repoRoot, orgName string, paths []string) ([]string, error) {
+	files := []string{fmt.Sprintf(orgConfigPathFormat, orgName)}
+	for _, p := range paths {
+		rel, err := filepath.Rel(repoRoot, p)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, filepath.ToSlash(rel))
+	}
+
+	return files, nil
+}
+
+// orgRole returns the role of username in orgName, admins or members, along
+// with where it is listed, searching the org.yaml and members.d fragments of
+// the org. The role is empty if username is neither.
+func orgRole(changes *changeSet, orgName, username string) (string, orgconfig.Source, error) {
+	files, err := orgMemberFiles(changes.repoRoot, orgName)
+	if err != nil {
+		return "", orgconfig.Source{}, err
+	}
+
+	for _, field := range []string{"admins", "members"} {
+		for _, path := range files {
+			file, err := changes.file(path)
+			if err != nil {
+				return "", orgconfig.Source{}, fmt.Errorf("reading config: %s", err)
+			}
+
+			config, err := file.decode()
+			if err != nil {
+				return "", orgconfig.Source{}, fmt.Errorf("reading config: %s", err)
+			}
+
+			list := config.Members
+			if field == "admins" {
+				list = config.Admins
+			}
+			if stringInSliceCaseAgnostic(list, username) {
+				return field, file.source(username, field), nil
+			}
+		}
+	}
+
+	return "", orgconfig.Source{}, nil
+}
+
+// findTeamNames returns the names leading to the team called name, searching
+// top-level teams and their children.
+func findTeamNames(teams map[string]org.Team, name string) []string {
+	for teamName, team := range teams {
+		if teamName == name {
+			return []string{teamName}
+		}
+		if names := findTeamNames(team.Children, name); names != nil {
+			return append([]string{teamName}, names...)
+		}
+	}
+
+	return nil
+}
+
+// findTeam locates a team within an org. The team is either referenced by its
+// name alone, in which case org.yaml and every teams.yaml of the org are
+// searched, or as <dir>/<name> where dir is the directory holding the
+// teams.yaml, e.g. sig-release/milestone-maintainers.
+func findTeam(repoRoot, orgName, team string) (*teamLocation, error) {
+	files, err := orgConfigFiles(repoRoot, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	name := team
+	if strings.Contains(team, "/") {
+		name = path.Base(team)
+		files = []string{path.Join(path.Dir(fmt.Sprintf(orgConfigPathFormat, orgName)), path.Dir(team), "teams.yaml")}
+	}
+
+	var found []teamLocation
+	for _, file := range files {
+		f, err := loadConfigFile(filepath.Join(repoRoot, file))
+		if err != nil {
+			return nil, fmt.Errorf("reading config: %s", err)
+		}
+
+		config, err := f.decode()
+		if err != nil {
+			return nil, fmt.Errorf("reading config: %s", err)
+		}
+
+		if names := findTeamNames(config.Teams, name); names != nil {
+			keys := teamKeys(names, "")
+			key, _ := f.lookup(keys[:len(keys)-1]...)
+			found = append(found, teamLocation{path: file, names: names, line: key.Line})
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("team %s not found in org %s", team, orgName)
+	case 1:
+		return &found[0], nil
+	default:
+		locations := []string{}
+		for _, l := range found {
+			locations = append(locations, l.String())
+		}
+		return nil, fmt.Errorf("team %s is defined more than once in org %s: %s", team, orgName, strings.Join(locations, ", "))
+	}
+}
+
+// teamByNames returns the team at the end of names, descending through child
+// teams. The names are expected to come from findTeamNames.
+func teamByNames(teams map[string]org.Team, names []string) org.Team {
+	team := teams[names[0]]
+	for _, name := range names[1:] {
+		team = team.Children[name]
+	}
+
+	return team
+}
+
+// AddMemberToTeams adds username as a member of each of teams within orgName.
+// Admins of the org are skipped, as they can only be team maintainers.
+func AddMemberToTeams(username, orgName string, teams []*teamLocation, changes *changeSet, results *summary) error {
+	role, source, err := orgRole(changes, orgName, username)
+	if err != nil {
+		return err
+	}
+	if role == "admins" {
+		results.skip(username, fmt.Sprintf("admin of org %s (%s), can only be added to teams as a maintainer", orgName, source))
+		return nil
+	}
+
+	for _, location := range teams {
+		fmt.Printf("adding %s to %s team\n", username, location)
+
+		file, err := changes.file(location.path)
+		if err != nil {
+			return fmt.Errorf("reading config: %s", err)
+		}
+
+		config, err := file.decode()
+		if err != nil {
+			return fmt.Errorf("reading config: %s", err)
+		}
+
+		team := strings.Join(location.names, "/")
+		t := teamByNames(config.Teams, location.names)
+		if stringInSliceCaseAgnostic(t.Members, username) || stringInSliceCaseAgnostic(t.Maintainers, username) {
+			field := "members"
+			if stringInSliceCaseAgnostic(t.Maintainers, username) {
+				field = "maintainers"
+			}
+			results.skip(username, fmt.Sprintf("already exists in team %s (%s)", team, file.source(username, teamKeys(location.names, field)...)))
+			continue
+		}
+
+		if _, err := file.addToList(username, teamKeys(location.names, "members")...); err != nil {
+			return fmt.Errorf("adding %s to %s team: %s", username, team, err)
+		}
+
+		changes.markModified(location.path)
+		results.changed(username, fmt.Sprintf("%s/%s", orgName, team))
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestFindTeam(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/kubernetes/org.yaml":                            "teams:\n  top:\n    privacy: closed\n",
+		"config/kubernetes/sig-release/teams.yaml":              "teams:\n  release:\n    teams:\n      child:\n        privacy: closed\n",
+		"config/kubernetes/sig-release/release-team/teams.yaml": "teams:\n  release-team:\n    privacy: closed\n",
+	})
+
+	cases := []struct {
+		team     string
+		expected *teamLocation
+	}{
+		{
+			team:     "top",
+			expected: &teamLocation{path: "config/kubernetes/org.yaml", names: []string{"top"}, line: 2},
+		},
+		{
+			team:     "child",
+			expected: &teamLocation{path: "config/kubernetes/sig-release/teams.yaml", names: []string{"release", "child"}, line: 4},
+		},
+		{
+			team:     "release-team",
+			expected: &teamLocation{path: "config/kubernetes/sig-release/release-team/teams.yaml", names: []string{"release-team"}, line: 2},
+		},
+		{
+			team:     "sig-release/release-team/release-team",
+			expected: &teamLocation{path: "config/kubernetes/sig-release/release-team/teams.yaml", names: []string{"release-team"}, line: 2},
+		},
+		{
+			team: "sig-release/release-team",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.team, func(t *testing.T) {
+			got, err := findTeam(root, "kubernetes", tc.team)
+			if tc.expected == nil {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected location %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFindTeamDefinedTwice(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/kubernetes/org.yaml":               "teams:\n  release:\n    privacy: closed\n",
+		"config/kubernetes/sig-release/teams.yaml": "teams:\n  other:\n    privacy: closed\n  release:\n    privacy: closed\n",
+	})
+
+	_, err := findTeam(root, "kubernetes", "release")
+	expected := "team release is defined more than once in org kubernetes: release (config/kubernetes/org.yaml:2), release (config/kubernetes/sig-release/teams.yaml:4)"
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error %v, expected %q", err, expected)
+	}
+}
+
+func TestOrgRole(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"config/kubernetes/org.yaml":               "admins:\n- alice\nmembers:\n- bob\n",
+		"config/kubernetes/members.d/sig-foo.yaml": "admins:\n- carol\nmembers:\n- Bob\n- dave\n",
+		"config/kubernetes/sig-foo/repos.yaml":     "repos:\n  erin: {}\n",
+	})
+	changes := newChangeSet(root)
+
+	cases := []struct {
+		username string
+		role     string
+		source   string
+	}{
+		{username: "alice", role: "admins", source: "config/kubernetes/org.yaml:2"},
+		{username: "bob", role: "members", source: "config/kubernetes/org.yaml:4"},
+		{username: "Carol", role: "admins", source: "config/kubernetes/members.d/sig-foo.yaml:2"},
+		{username: "dave", role: "members", source: "config/kubernetes/members.d/sig-foo.yaml:5"},
+		{username: "erin"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.username, func(t *testing.T) {
+			role, source, err := orgRole(changes, "kubernetes", tc.username)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.TrimPrefix(source.String(), root+"/"); role != tc.role || got != tc.source {
+				t.Errorf("unexpected role %q at %q, expected %q at %q", role, got, tc.role, tc.source)
+			}
+		})
+	}
+}
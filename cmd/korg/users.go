@@ -0,0 +1,240 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const maxLoginLength = 39
+
+// githubLoginRe matches alphanumeric characters separated by single hyphens,
+// which is what GitHub accepts for logins.
+var githubLoginRe = regexp.MustCompile(`^[a-zA-Z0-9]+(-[a-zA-Z0-9]+)*$`)
+
+var errUserNotFound = errors.New("user not found")
+
+// GitHubUser is what a UserLookup knows about a GitHub account.
+type GitHubUser struct {
+	Login string `json:"login"`
+	ID    int    `json:"id,omitempty"`
+	// RenamedTo is set when the account has since changed its login. Only
+	// user caches know it: the GitHub API reports renamed accounts as not
+	// found.
+	RenamedTo string `json:"renamed_to,omitempty"`
+}
+
+// UserLookup looks GitHub accounts up by login. It returns errUserNotFound if
+// the account does not exist.
+type UserLookup interface {
+	LookupUser(login string) (*GitHubUser, error)
+}
+
+func validateLoginSyntax(login string) error {
+	if len(login) > maxLoginLength {
+		return fmt.Errorf("is longer than %d characters", maxLoginLength)
+	}
+
+	if !githubLoginRe.MatchString(login) {
+		return fmt.Errorf("may only contain alphanumeric characters or single hyphens, and cannot begin or end with a hyphen")
+	}
+
+	return nil
+}
+
+// fileUserLookup looks users up in a local JSON file holding a list of
+// GitHubUser, e.g. a cache of previous lookups or a test fixture.
+type fileUserLookup struct {
+	users map[string]GitHubUser
+}
+
+func newFileUserLookup(path string) (*fileUserLookup, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	var users []GitHubUser
+	if err := json.Unmarshal(contents, &users); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal users from %s: %s", path, err)
+	}
+
+	l := &fileUserLookup{users: map[string]GitHubUser{}}
+	for _, user := range users {
+		l.users[github.NormLogin(user.Login)] = user
+	}
+
+	return l, nil
+}
+
+func (l *fileUserLookup) LookupUser(login string) (*GitHubUser, error) {
+	user, ok := l.users[github.NormLogin(login)]
+	if !ok {
+		return nil, errUserNotFound
+	}
+
+	return &user, nil
+}
+
+// githubUserLookup looks users up using the GitHub REST API.
+type githubUserLookup struct {
+	client  *http.Client
+	baseURL string
+	token   string
+}
+
+func newGitHubUserLookup(tokenPath string) (*githubUserLookup, error) {
+	l := &githubUserLookup{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: "https://api.github.com",
+	}
+
+	if tokenPath != "" {
+		token, err := os.ReadFile(tokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read token from %s: %s", tokenPath, err)
+		}
+		l.token = strings.TrimSpace(string(token))
+	}
+
+	return l, nil
+}
+
+func (l *githubUserLookup) LookupUser(login string) (*GitHubUser, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/users/%s", l.baseURL, url.PathEscape(login)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if l.token != "" {
+		req.Header.Set("Authorization", "Bearer "+l.token)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errUserNotFound
+	default:
+		return nil, fmt.Errorf("bad status code from github for %s: %d", login, resp.StatusCode)
+	}
+
+	var user GitHubUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("unable to parse json from github: %w", err)
+	}
+
+	return &user, nil
+}
+
+// chainedUserLookup tries each lookup in turn until one knows the user.
+type chainedUserLookup []UserLookup
+
+func (c chainedUserLookup) LookupUser(login string) (*GitHubUser, error) {
+	for _, l := range c {
+		user, err := l.LookupUser(login)
+		if errors.Is(err, errUserNotFound) {
+			continue
+		}
+
+		return user, err
+	}
+
+	return nil, errUserNotFound
+}
+
+// newUserLookup returns the lookup configured by options, or nil if users
+// should only be validated syntactically.
+func newUserLookup(options Options) (UserLookup, error) {
+	lookups := chainedUserLookup{}
+	if options.UserCache != "" {
+		l, err := newFileUserLookup(options.UserCache)
+		if err != nil {
+			return nil, err
+		}
+		lookups = append(lookups, l)
+	}
+
+	if options.CheckGitHub {
+		l, err := newGitHubUserLookup(options.GitHubTokenPath)
+		if err != nil {
+			return nil, err
+		}
+		lookups = append(lookups, l)
+	}
+
+	if len(lookups) == 0 {
+		return nil, nil
+	}
+
+	return lookups, nil
+}
+
+// validateUsernames checks usernames against GitHub's login rules and, if
+// lookup is set, against existing accounts. Invalid, nonexistent and renamed
+// accounts are skipped in results. The valid usernames are returned using the
+// canonical casing of their account.
+func validateUsernames(usernames []string, lookup UserLookup, results *summary) ([]string, error) {
+	valid := []string{}
+	for _, username := range usernames {
+		if err := validateLoginSyntax(username); err != nil {
+			results.skip(username, fmt.Sprintf("invalid GitHub username: %s", err))
+			continue
+		}
+
+		if lookup == nil {
+			valid = append(valid, username)
+			continue
+		}
+
+		user, err := lookup.LookupUser(username)
+		switch {
+		case errors.Is(err, errUserNotFound):
+			results.skip(username, "GitHub account not found, it may have been renamed or deleted")
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("looking up %s: %s", username, err)
+		case user.RenamedTo != "":
+			results.skip(username, fmt.Sprintf("GitHub account was renamed to %s", user.RenamedTo))
+			continue
+		}
+
+		if user.Login != username {
+			fmt.Printf("using canonical casing %s for %s\n", user.Login, username)
+			results.rename(username, user.Login)
+		}
+		valid = append(valid, user.Login)
+	}
+
+	return valid, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateLoginSyntax(t *testing.T) {
+	cases := []struct {
+		login       string
+		expectError bool
+	}{
+		{login: "palnabarun"},
+		{login: "MadhavJivrajani"},
+		{login: "k8s-ci-robot"},
+		{login: "249043822"},
+		{login: strings.Repeat("a", 39)},
+		{login: strings.Repeat("a", 40), expectError: true},
+		{login: "-leading", expectError: true},
+		{login: "trailing-", expectError: true},
+		{login: "double--hyphen", expectError: true},
+		{login: "under_score", expectError: true},
+		{login: "@palnabarun", expectError: true},
+	}
+
+	for _, c := range cases {
+		err := validateLoginSyntax(c.login)
+		if !c.expectError && err != nil {
+			t.Errorf("unexpected error for %q: %v", c.login, err)
+		}
+		if c.expectError && err == nil {
+			t.Errorf("expected error for %q", c.login)
+		}
+	}
+}
+
+func TestValidateUsernames(t *testing.T) {
+	lookup, err := newFileUserLookup("testdata/users.json")
+	if err != nil {
+		t.Fatalf("loading users: %v", err)
+	}
+
+	usernames := []string{"madhavjivrajani", "palnabarun", "old-login", "missing", "bad--login"}
+	results := newSummary(append([]string{}, usernames...))
+	valid, err := validateUsernames(usernames, lookup, results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"MadhavJivrajani", "palnabarun"}; !reflect.DeepEqual(valid, expected) {
+		t.Errorf("unexpected valid usernames: got %v, expected %v", valid, expected)
+	}
+
+	if expected := []string{"MadhavJivrajani", "palnabarun", "old-login", "missing", "bad--login"}; !reflect.DeepEqual(results.usernames, expected) {
+		t.Errorf("unexpected summary usernames: got %v, expected %v", results.usernames, expected)
+	}
+
+	for _, username := range []string{"old-login", "missing", "bad--login"} {
+		if len(results.skipped[username]) != 1 {
+			t.Errorf("expected %s to be skipped once, got %v", username, results.skipped[username])
+		}
+	}
+}
+
+func TestGitHubUserLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/users/palnabarun":
+			w.Write([]byte(`{"login": "palnabarun", "id": 23456}`))
+		case "/users/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	lookup := &githubUserLookup{client: server.Client(), baseURL: server.URL, token: "token"}
+
+	user, err := lookup.LookupUser("palnabarun")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Login != "palnabarun" || user.ID != 23456 {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	if _, err := lookup.LookupUser("missing"); !errors.Is(err, errUserNotFound) {
+		t.Errorf("expected user not found, got %v", err)
+	}
+
+	if _, err := lookup.LookupUser("broken"); err == nil || errors.Is(err, errUserNotFound) {
+		t.Errorf("expected an error other than user not found, got %v", err)
+	}
+}
@@ -17,21 +17,15 @@ limitations under the License.
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
-	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/prow/pkg/config/org"
-	"sigs.k8s.io/yaml"
 
-	"github.com/hound-search/hound/client"
+	"k8s.io/org/pkg/orgconfig"
 )
 
 func stringInSlice(slice []string, key string) bool {
@@ -55,7 +49,7 @@ func stringInSliceCaseAgnostic(slice []string, key string) bool {
 	return false
 }
 
-func findInvalidOrgs(orgs []string) []string {
+func findInvalidOrgs(validOrgs, orgs []string) []string {
 	invalid := []string{}
 
 	for _, org := range orgs {
@@ -68,149 +62,231 @@ func findInvalidOrgs(orgs []string) []string {
 }
 
 func readConfig(path string) (*org.Config, error) {
-	contents, err := os.ReadFile(path)
+	config, err := orgconfig.ReadConfig(path)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+		return nil, fmt.Errorf("unable to read config from %s: %s", path, err)
 	}
 
-	config := org.Config{}
-	if err := yaml.Unmarshal(contents, &config); err != nil {
-		return nil, fmt.Errorf("unable to unmarshal config from %s: %s", path, err)
-	}
-
-	return &config, nil
+	return config, nil
 }
 
-func saveConfig(path string, config *org.Config) error {
-	b, err := yaml.Marshal(config)
-	if err != nil {
-		return fmt.Errorf("unable to marshal config: %s", err)
+// readUsernames returns the usernames given as arguments followed by the ones
+// listed in path, if set. The file lists one username per line; blank lines
+// and lines starting with # are ignored. Duplicate usernames are dropped.
+func readUsernames(args []string, path string) ([]string, error) {
+	candidates := append([]string{}, args...)
+	if path != "" {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+		}
+
+		for _, line := range strings.Split(string(contents), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			candidates = append(candidates, line)
+		}
 	}
 
-	info, err := os.Stat(path)
-	if err != nil {
-		return fmt.Errorf("unable to fetch info for %s: %s", path, err)
+	usernames := []string{}
+	for _, username := range candidates {
+		username = strings.TrimSpace(username)
+		if username == "" {
+			return nil, fmt.Errorf("empty username specified")
+		}
+		if stringInSliceCaseAgnostic(usernames, username) {
+			continue
+		}
+		usernames = append(usernames, username)
 	}
 
-	if err := os.WriteFile(path, b, info.Mode()); err != nil {
-		return fmt.Errorf("unable to write to %s: %s", path, err)
+	return usernames, nil
+}
+
+// changeSet holds the config files loaded for editing, so that every change
+// to the same file accumulates before they are all saved at once.
+type changeSet struct {
+	repoRoot string
+	files    map[string]*configFile
+	modified []string
+}
+
+func newChangeSet(repoRoot string) *changeSet {
+	return &changeSet{
+		repoRoot: repoRoot,
+		files:    map[string]*configFile{},
 	}
-	return nil
 }
 
-func commitChanges(repoRoot string, configsModified []string, message string) error {
-	r, err := git.PlainOpen(repoRoot)
-	if err != nil {
-		return fmt.Errorf("unable to open repository: %s", err)
+// file returns the config file at path, relative to the repo root, loading it
+// on first use.
+func (c *changeSet) file(path string) (*configFile, error) {
+	if f, ok := c.files[path]; ok {
+		return f, nil
 	}
 
-	w, err := r.Worktree()
+	f, err := loadConfigFile(filepath.Join(c.repoRoot, path))
 	if err != nil {
-		return fmt.Errorf("unable to fetch worktree: %s", err)
+		return nil, err
 	}
 
-	for _, configModified := range configsModified {
-		_, err := w.Add(configModified)
-		if err != nil {
-			return fmt.Errorf("unable to stage changes: %s", err)
-		}
+	c.files[path] = f
+	return f, nil
+}
+
+func (c *changeSet) markModified(path string) {
+	if !stringInSlice(c.modified, path) {
+		c.modified = append(c.modified, path)
 	}
+}
 
-	_, err = w.Commit(message, &git.CommitOptions{})
-	if err != nil {
-		return fmt.Errorf("unable to commit changes: %s", err)
+func (c *changeSet) save() error {
+	for _, path := range c.modified {
+		fmt.Printf("saving config %s\n", path)
+		if err := c.files[path].save(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func caseAgnosticSort(arr []string) {
-	sort.Slice(arr, func(i, j int) bool {
-		return strings.ToLower(arr[i]) < strings.ToLower(arr[j])
-	})
+// summary records what happened to each user of a bulk operation, so that
+// users who are skipped don't abort the operation for everyone else.
+type summary struct {
+	usernames []string
+	changes   map[string][]string
+	skipped   map[string][]string
 }
 
-func IsOwner(username string) (bool, error) {
-	url := fmt.Sprintf("https://cs.k8s.io/api/v1/search?stats=fosho&repos=*&rng=:20&q=%s&i=fosho&files=OWNERS&excludeFiles=vendor/", username)
-	resp, err := http.Get(url)
-	if err != nil {
-		return false, err
+func newSummary(usernames []string) *summary {
+	return &summary{
+		usernames: usernames,
+		changes:   map[string][]string{},
+		skipped:   map[string][]string{},
 	}
+}
 
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, err
+// rename replaces username with to, e.g. once its canonical casing is known.
+func (s *summary) rename(username, to string) {
+	for i, u := range s.usernames {
+		if u == username {
+			s.usernames[i] = to
+		}
 	}
+}
 
-	var r client.Response
-
-	err = json.Unmarshal(body, &r)
-	if err != nil {
-		return false, err
-	}
+// changed records that username was added to or removed from target, an org
+// or an org/team.
+func (s *summary) changed(username, target string) {
+	s.changes[username] = append(s.changes[username], target)
+}
 
-	return r.Stats.FilesOpened > 0, nil
+func (s *summary) skip(username, reason string) {
+	fmt.Printf("skipping %s: %s\n", username, reason)
+	s.skipped[username] = append(s.skipped[username], reason)
 }
 
-func unmarshalFromFile(path string) (*org.Config, error) {
-	buf, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read: %v", err)
+// changedUsers returns the users with at least one change, in input order.
+func (s *summary) changedUsers() []string {
+	changed := []string{}
+	for _, username := range s.usernames {
+		if len(s.changes[username]) > 0 {
+			changed = append(changed, username)
+		}
 	}
 
-	return unmarshal(buf)
+	return changed
 }
 
-func unmarshal(buf []byte) (*org.Config, error) {
-	var cfg org.Config
-	if err := yaml.Unmarshal(buf, &cfg, yaml.DisallowUnknownFields); err != nil {
-		return nil, fmt.Errorf("unmarshal: %v", err)
+func (s *summary) print(verb string) {
+	fmt.Println()
+	for _, username := range s.usernames {
+		if changes := s.changes[username]; len(changes) > 0 {
+			fmt.Printf("%s %s: %s\n", verb, username, strings.Join(changes, ", "))
+		}
 	}
-	return &cfg, nil
+	for _, username := range s.usernames {
+		if reasons := s.skipped[username]; len(reasons) > 0 {
+			fmt.Printf("skipped %s: %s\n", username, strings.Join(reasons, "; "))
+		}
+	}
+	changed := len(s.changedUsers())
+	fmt.Printf("%s %d, skipped %d of %d users\n", verb, changed, len(s.usernames)-changed, len(s.usernames))
 }
 
-func LoadOrgs(o Options) (map[string]org.Config, error) {
-	config := map[string]org.Config{}
-	for _, orgName := range o.Orgs {
-		path := fmt.Sprintf("%s/config/%s/org.yaml", o.RepoRoot, orgName)
-
-		cfg, err := unmarshalFromFile(path)
-		if err != nil {
-			return nil, fmt.Errorf("error in %s: %v", path, err)
+// commitMessage describes the changes in a commit message. verb is in the
+// imperative, e.g. "add". A single user gets a subject naming the orgs they
+// were changed in, and a body listing their teams if any; otherwise the body
+// lists every user and what changed for them.
+func (s *summary) commitMessage(verb, preposition string, orgs []string) string {
+	changed := s.changedUsers()
+	if len(changed) == 1 {
+		username := changed[0]
+		userOrgs, teams := []string{}, []string{}
+		for _, target := range s.changes[username] {
+			orgName, _, isTeam := strings.Cut(target, "/")
+			if !stringInSlice(userOrgs, orgName) {
+				userOrgs = append(userOrgs, orgName)
+			}
+			if isTeam {
+				teams = append(teams, "- "+target)
+			}
 		}
 
-		if cfg.Teams == nil {
-			cfg.Teams = map[string]org.Team{}
+		subject := fmt.Sprintf("%s %s %s %s", verb, username, preposition, strings.Join(userOrgs, ", "))
+		if len(teams) == 0 {
+			return subject
 		}
-		prefix := filepath.Dir(path)
-		err = filepath.Walk(prefix, func(path string, info os.FileInfo, err error) error {
-			switch {
-			case path == prefix:
-				return nil // Skip base dir
-			case info.IsDir() && filepath.Dir(path) != prefix:
-				logrus.Infof("Skipping %s and its children", path)
-				return filepath.SkipDir // Skip prefix/foo/bar/ dirs
-			case !info.IsDir() && filepath.Dir(path) == prefix:
-				return nil // Ignore prefix/foo files
-			case filepath.Base(path) == "teams.yaml":
-				teamCfg, err := unmarshalFromFile(path)
-				if err != nil {
-					return fmt.Errorf("error in %s: %v", path, err)
-				}
-
-				for name, team := range teamCfg.Teams {
-					cfg.Teams[name] = team
-				}
-			}
-			return nil
-		})
+		return strings.Join(append([]string{subject, "", "teams:"}, teams...), "\n")
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s %d users %s %s", verb, len(changed), preposition, strings.Join(orgs, ", ")),
+		"",
+	}
+	for _, username := range changed {
+		lines = append(lines, fmt.Sprintf("- %s: %s", username, strings.Join(s.changes[username], ", ")))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func commitChanges(repoRoot string, configsModified []string, message string) error {
+	r, err := git.PlainOpen(repoRoot)
+	if err != nil {
+		return fmt.Errorf("unable to open repository: %s", err)
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return fmt.Errorf("unable to fetch worktree: %s", err)
+	}
+
+	for _, configModified := range configsModified {
+		_, err := w.Add(configModified)
 		if err != nil {
-			return nil, fmt.Errorf("merge teams %s: %v", path, err)
+			return fmt.Errorf("unable to stage changes: %s", err)
 		}
+	}
+
+	_, err = w.Commit(message, &git.CommitOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to commit changes: %s", err)
+	}
 
-		config[orgName] = *cfg
+	return nil
+}
+
+// LoadOrgs reads the config of each org of o, with the teams of every
+// teams.yaml of the org merged in.
+func LoadOrgs(o Options) (map[string]org.Config, error) {
+	repo, err := orgconfig.LoadOrgs(filepath.Join(o.RepoRoot, "config"), o.Orgs...)
+	if err != nil {
+		return nil, err
 	}
-	return config, nil
+
+	return repo.FullConfig().Orgs, nil
 }
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadUsernames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.txt")
+	if err := os.WriteFile(path, []byte("# inactive members\nuser2\n\n  User1  \nuser3\n"), 0644); err != nil {
+		t.Fatalf("writing users file: %v", err)
+	}
+
+	cases := []struct {
+		desc        string
+		args        []string
+		path        string
+		expected    []string
+		expectError bool
+	}{
+		{
+			desc:     "from arguments only",
+			args:     []string{"user1", "user2"},
+			expected: []string{"user1", "user2"},
+		},
+		{
+			desc:     "from arguments and a file, dropping duplicates",
+			args:     []string{"user1"},
+			path:     path,
+			expected: []string{"user1", "user2", "user3"},
+		},
+		{
+			desc:        "with an empty argument",
+			args:        []string{"user1", " "},
+			expectError: true,
+		},
+		{
+			desc:        "with a missing file",
+			path:        filepath.Join(t.TempDir(), "missing.txt"),
+			expectError: true,
+		},
+	}
+
+	for _, c := range cases {
+		got, err := readUsernames(c.args, c.path)
+		if !c.expectError && err != nil {
+			t.Errorf("unexpected error %s: %v", c.desc, err)
+		}
+		if c.expectError && err == nil {
+			t.Errorf("expected error %s", c.desc)
+		}
+		if !c.expectError && !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("unexpected usernames %s: got %v, expected %v", c.desc, got, c.expected)
+		}
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	cases := []struct {
+		desc     string
+		changes  map[string][]string
+		expected string
+	}{
+		{
+			desc:     "single user, orgs only",
+			changes:  map[string][]string{"bob": {"kubernetes", "kubernetes-sigs"}},
+			expected: "remove bob from kubernetes, kubernetes-sigs",
+		},
+		{
+			desc: "single user with teams",
+			changes: map[string][]string{"bob": {
+				"kubernetes", "kubernetes/sig-foo-leads", "kubernetes/sig-foo-leads/child", "kubernetes-sigs", "kubernetes-sigs/foo-admins",
+			}},
+			expected: `remove bob from kubernetes, kubernetes-sigs
+
+teams:
+- kubernetes/sig-foo-leads
+- kubernetes/sig-foo-leads/child
+- kubernetes-sigs/foo-admins`,
+		},
+		{
+			desc:     "single user in teams only",
+			changes:  map[string][]string{"bob": {"kubernetes/sig-foo-leads"}},
+			expected: "remove bob from kubernetes\n\nteams:\n- kubernetes/sig-foo-leads",
+		},
+		{
+			desc: "several users",
+			changes: map[string][]string{
+				"alice": {"kubernetes"},
+				"bob":   {"kubernetes", "kubernetes/sig-foo-leads"},
+			},
+			expected: `remove 2 users from kubernetes, kubernetes-sigs
+
+- alice: kubernetes
+- bob: kubernetes, kubernetes/sig-foo-leads`,
+		},
+	}
+
+	for _, c := range cases {
+		s := newSummary([]string{"alice", "bob", "carol"})
+		for username, targets := range c.changes {
+			for _, target := range targets {
+				s.changed(username, target)
+			}
+		}
+		if got := s.commitMessage("remove", "from", []string{"kubernetes", "kubernetes-sigs"}); got != c.expected {
+			t.Errorf("%s: unexpected message:\n%s\nexpected:\n%s", c.desc, got, c.expected)
+		}
+	}
+}
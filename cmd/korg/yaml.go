@@ -0,0 +1,490 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/prow/pkg/config/org"
+
+	"k8s.io/org/pkg/order"
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// configFile is a YAML config file edited in place.
+//
+// The file is parsed into a node tree only to locate what needs to change;
+// edits are applied to the raw lines of the file. This preserves comments, key
+// order and formatting, so that adding a member to a list touches exactly one
+// line.
+type configFile struct {
+	path  string
+	lines []string
+	root  *yaml.Node
+}
+
+func loadConfigFile(path string) (*configFile, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	f := &configFile{
+		path:  path,
+		lines: strings.Split(string(contents), "\n"),
+	}
+	if err := f.parse(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// parse rebuilds the node tree from the current lines. It must be called
+// after every edit, as edits shift the positions of the nodes that follow.
+func (f *configFile) parse() error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(f.lines, "\n")), &doc); err != nil {
+		return fmt.Errorf("unable to parse %s: %s", f.path, err)
+	}
+
+	if len(doc.Content) == 0 {
+		f.root = &yaml.Node{Kind: yaml.MappingNode}
+		return nil
+	}
+
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a YAML mapping", f.path)
+	}
+
+	f.root = doc.Content[0]
+	return nil
+}
+
+func (f *configFile) save() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("unable to fetch info for %s: %s", f.path, err)
+	}
+
+	if err := os.WriteFile(f.path, []byte(strings.Join(f.lines, "\n")), info.Mode()); err != nil {
+		return fmt.Errorf("unable to write to %s: %s", f.path, err)
+	}
+	return nil
+}
+
+// decode unmarshals the current contents of the file into an org config.
+func (f *configFile) decode() (*org.Config, error) {
+	config, err := orgconfig.Unmarshal([]byte(strings.Join(f.lines, "\n")))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode config from %s: %s", f.path, err)
+	}
+
+	return config, nil
+}
+
+// lookup walks the mappings along keys and returns the node of the last key
+// and its value, or nil nodes if any key along the way does not exist.
+func (f *configFile) lookup(keys ...string) (*yaml.Node, *yaml.Node) {
+	var key *yaml.Node
+	value := f.root
+	for _, k := range keys {
+		if value == nil || value.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+
+		key, value = mappingEntry(value, k)
+	}
+
+	return key, value
+}
+
+// source returns where login is listed in the sequence at keys, falling back
+// to where the last of keys is defined, then to the file itself.
+func (f *configFile) source(login string, keys ...string) orgconfig.Source {
+	key, list := f.lookup(keys...)
+	if key == nil {
+		return orgconfig.Source{File: f.path}
+	}
+	if list.Kind == yaml.SequenceNode {
+		for _, item := range list.Content {
+			if order.Equal(item.Value, login) {
+				return orgconfig.Source{File: f.path, Line: item.Line}
+			}
+		}
+	}
+
+	return orgconfig.Source{File: f.path, Line: key.Line}
+}
+
+func mappingEntry(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+
+	return nil, nil
+}
+
+// addToList adds value to the sequence at keys, keeping the sequence in the
+// order of package order. The sequence and its key are created if needed.
+// It returns false if value was already present.
+func (f *configFile) addToList(value string, keys ...string) (bool, error) {
+	item, err := formatScalar(value)
+	if err != nil {
+		return false, err
+	}
+
+	key, list := f.lookup(keys...)
+	switch {
+	case key == nil:
+		return true, f.insertKey(keys, item)
+
+	case list.Kind == yaml.ScalarNode && list.Tag == "!!null":
+		// key without a value, e.g. "members:", or with an explicit null,
+		// e.g. "members: ~", which is dropped
+		if list.Value != "" {
+			if list.Line != key.Line {
+				return false, fmt.Errorf("%s:%d: editing a null value on its own line is unsupported", f.path, list.Line)
+			}
+
+			line := f.lines[list.Line-1]
+			f.lines[list.Line-1] = strings.TrimRight(line[:list.Column-1], " ")
+			if rest := strings.TrimSpace(line[list.Column-1+len(list.Value):]); rest != "" {
+				f.lines[list.Line-1] += " " + rest
+			}
+		}
+		indent := strings.Repeat(" ", key.Column-1)
+		f.insertLines(key.Line, indent+"- "+item)
+
+	case list.Kind == yaml.SequenceNode && list.Style&yaml.FlowStyle != 0:
+		if len(list.Content) > 0 {
+			return false, fmt.Errorf("%s:%d: editing non-empty flow sequences is unsupported", f.path, list.Line)
+		}
+
+		// empty flow sequence, e.g. "members: []"
+		line := f.lines[list.Line-1]
+		f.lines[list.Line-1] = strings.TrimRight(line[:list.Column-1], " ")
+		if rest := strings.TrimSpace(line[list.Column+1:]); rest != "" {
+			f.lines[list.Line-1] += " " + rest
+		}
+		indent := strings.Repeat(" ", key.Column-1)
+		f.insertLines(list.Line, indent+"- "+item)
+
+	case list.Kind == yaml.SequenceNode:
+		for _, existing := range list.Content {
+			if order.Equal(existing.Value, value) {
+				return false, nil
+			}
+		}
+
+		// reuse the prefix of an existing item, e.g. "    - "
+		first := list.Content[0]
+		prefix := f.lines[first.Line-1][:first.Column-1]
+
+		at := list.Content[len(list.Content)-1].Line
+		for _, existing := range list.Content {
+			if order.Less(value, existing.Value) {
+				at = headLine(existing) - 1
+				break
+			}
+		}
+		f.insertLines(at, prefix+item)
+
+	default:
+		return false, fmt.Errorf("%s:%d: %s is not a list", f.path, list.Line, strings.Join(keys, "."))
+	}
+
+	return true, f.parse()
+}
+
+// removeFromList removes every occurrence of the login value from the
+// sequence at keys, along with the comments preceding it. If the sequence ends
+// up empty, its key is removed as well, leaving an empty mapping, e.g.
+// "foo: {}", if it was the only key of its mapping. It returns false if value
+// was not present.
+func (f *configFile) removeFromList(value string, keys ...string) (bool, error) {
+	key, list := f.lookup(keys...)
+	if key == nil || list.Kind != yaml.SequenceNode {
+		return false, nil
+	}
+
+	removed := false
+	for {
+		var found *yaml.Node
+		for _, existing := range list.Content {
+			if order.Equal(existing.Value, value) {
+				found = existing
+				break
+			}
+		}
+		if found == nil {
+			break
+		}
+
+		if list.Style&yaml.FlowStyle != 0 {
+			return false, fmt.Errorf("%s:%d: editing flow sequences is unsupported", f.path, list.Line)
+		}
+
+		if len(list.Content) == 1 {
+			parentKey, mapping := f.lookup(keys[:len(keys)-1]...)
+			f.deleteLines(headLine(key)-1, found.Line)
+			if parentKey != nil && len(mapping.Content) == 2 {
+				f.emptyMapping(parentKey)
+			}
+		} else {
+			f.deleteLines(headLine(found)-1, found.Line)
+		}
+		removed = true
+
+		if err := f.parse(); err != nil {
+			return false, err
+		}
+		if key, list = f.lookup(keys...); key == nil {
+			break
+		}
+	}
+
+	return removed, nil
+}
+
+// insertKey adds the last of keys, holding a sequence with a single item, to
+// the mapping at the preceding keys. Keys are kept in alphabetical order.
+func (f *configFile) insertKey(keys []string, item string) error {
+	name := keys[len(keys)-1]
+	return f.insertEntry(keys, name+":", "- "+item)
+}
+
+// insertEntry adds lines, indented like the entries of the mapping at the
+// preceding keys, to that mapping in the alphabetical position of the last
+// of keys.
+func (f *configFile) insertEntry(keys []string, lines ...string) error {
+	parentKey, mapping := f.lookup(keys[:len(keys)-1]...)
+	if parentKey != nil && mapping.Kind == yaml.MappingNode && mapping.Style&yaml.FlowStyle != 0 {
+		if len(mapping.Content) > 0 || mapping.Line != parentKey.Line {
+			return fmt.Errorf("%s:%d: editing non-empty flow mappings is unsupported", f.path, mapping.Line)
+		}
+
+		// empty flow mapping, e.g. "foo: {}"
+		line := f.lines[mapping.Line-1]
+		f.lines[mapping.Line-1] = strings.TrimRight(line[:mapping.Column-1], " ")
+		if rest := strings.TrimSpace(line[mapping.Column+1:]); rest != "" {
+			f.lines[mapping.Line-1] += " " + rest
+		}
+		indent := strings.Repeat(" ", parentKey.Column+1)
+		newLines := make([]string, 0, len(lines))
+		for _, line := range lines {
+			newLines = append(newLines, indent+line)
+		}
+		f.insertLines(parentKey.Line, newLines...)
+		return f.parse()
+	}
+	if mapping == nil || mapping.Kind != yaml.MappingNode || len(mapping.Content) == 0 {
+		return fmt.Errorf("%s: unable to add %s, parent mapping not found", f.path, strings.Join(keys, "."))
+	}
+
+	name := keys[len(keys)-1]
+	first := mapping.Content[0]
+	indent := strings.Repeat(" ", first.Column-1)
+	newLines := make([]string, 0, len(lines))
+	for _, line := range lines {
+		newLines = append(newLines, indent+line)
+	}
+
+	at := -1
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value > name {
+			at = headLine(mapping.Content[i]) - 1
+			break
+		}
+	}
+	if at < 0 {
+		at = f.entryEnd(mapping.Content[len(mapping.Content)-2])
+	}
+
+	f.insertLines(at, newLines...)
+	return f.parse()
+}
+
+// setScalar sets the value at keys to the plain scalar value, adding the key
+// if needed. It returns false if the value already was value, unquoted.
+func (f *configFile) setScalar(value string, keys ...string) (bool, error) {
+	item, err := formatScalar(value)
+	if err != nil {
+		return false, err
+	}
+
+	key, node := f.lookup(keys...)
+	switch {
+	case key == nil:
+		return true, f.insertEntry(keys, keys[len(keys)-1]+": "+item)
+
+	case node.Kind == yaml.ScalarNode && node.Line == key.Line:
+		if node.Value == value && node.Style == 0 {
+			return false, nil
+		}
+
+		line := f.lines[node.Line-1][:node.Column-1] + item
+		if node.LineComment != "" {
+			line += " " + node.LineComment
+		}
+		f.lines[node.Line-1] = line
+
+	default:
+		return false, fmt.Errorf("%s:%d: %s is not a single line scalar", f.path, node.Line, strings.Join(keys, "."))
+	}
+
+	return true, f.parse()
+}
+
+// sortList sorts the sequence at keys in the order of package order and
+// removes duplicate logins, keeping the first. Comments preceding an item move
+// along with it. It returns false if the sequence was already sorted and free
+// of duplicates.
+func (f *configFile) sortList(keys ...string) (bool, error) {
+	key, list := f.lookup(keys...)
+	if key == nil || list.Kind != yaml.SequenceNode || len(list.Content) == 0 {
+		return false, nil
+	}
+	if list.Style&yaml.FlowStyle != 0 {
+		return false, fmt.Errorf("%s:%d: formatting flow sequences is unsupported", f.path, list.Line)
+	}
+
+	type item struct {
+		value string
+		lines []string
+	}
+
+	// each item owns its line and the lines since the previous item, i.e. its
+	// comments
+	items := make([]item, 0, len(list.Content))
+	from := headLine(list.Content[0]) - 1
+	for _, node := range list.Content {
+		if node.Kind != yaml.ScalarNode {
+			return false, fmt.Errorf("%s:%d: %s must only hold scalars", f.path, node.Line, strings.Join(keys, "."))
+		}
+		items = append(items, item{value: node.Value, lines: f.lines[from:node.Line]})
+		from = node.Line
+	}
+	start, end := headLine(list.Content[0])-1, from
+
+	sorted := make([]item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return order.Less(sorted[i].value, sorted[j].value)
+	})
+
+	lines := []string{}
+	for i, it := range sorted {
+		if i > 0 && order.Equal(sorted[i-1].value, it.value) {
+			continue
+		}
+		lines = append(lines, it.lines...)
+	}
+
+	if strings.Join(lines, "\n") == strings.Join(f.lines[start:end], "\n") {
+		return false, nil
+	}
+
+	out := make([]string, 0, len(f.lines))
+	out = append(out, f.lines[:start]...)
+	out = append(out, lines...)
+	f.lines = append(out, f.lines[end:]...)
+	return true, f.parse()
+}
+
+// entryEnd returns the number of the last line belonging to the mapping entry
+// at key, i.e. the key line and every following line indented deeper than the
+// key, or holding a sequence item at the same indentation.
+func (f *configFile) entryEnd(key *yaml.Node) int {
+	col := key.Column - 1
+	end := key.Line
+	for i := key.Line; i < len(f.lines); i++ {
+		line := f.lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		switch {
+		case trimmed == "":
+			continue
+		case indent > col, indent == col && strings.HasPrefix(trimmed, "-"):
+			end = i + 1
+			continue
+		}
+		break
+	}
+
+	return end
+}
+
+// insertLines inserts lines after line number at (0 inserts at the top).
+func (f *configFile) insertLines(at int, lines ...string) {
+	out := make([]string, 0, len(f.lines)+len(lines))
+	out = append(out, f.lines[:at]...)
+	out = append(out, lines...)
+	f.lines = append(out, f.lines[at:]...)
+}
+
+// emptyMapping sets the value of the mapping entry at key, left without any
+// nested entries, to an empty flow mapping.
+func (f *configFile) emptyMapping(key *yaml.Node) {
+	line := f.lines[key.Line-1]
+	colon := key.Column - 1 + strings.Index(line[key.Column-1:], ":")
+	f.lines[key.Line-1] = line[:colon+1] + " {}" + line[colon+1:]
+}
+
+// deleteLines deletes lines numbered from+1 to to, inclusive.
+func (f *configFile) deleteLines(from, to int) {
+	f.lines = append(f.lines[:from], f.lines[to:]...)
+}
+
+// headLine returns the number of the first line of the comment preceding
+// node, or of node itself if it has none.
+func headLine(node *yaml.Node) int {
+	if node.HeadComment == "" {
+		return node.Line
+	}
+
+	return node.Line - strings.Count(node.HeadComment, "\n") - 1
+}
+
+// formatScalar renders value as a YAML scalar, quoting it when it would not
+// otherwise be read back as a string, e.g. numeric usernames.
+func formatScalar(value string) (string, error) {
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal %q: %s", value, err)
+	}
+
+	return string(bytes.TrimSuffix(b, []byte("\n"))), nil
+}
+
+// teamKeys returns the keys leading to the field of the team at the end of
+// names, descending through child teams.
+func teamKeys(names []string, field string) []string {
+	keys := []string{}
+	for _, name := range names {
+		keys = append(keys, "teams", name)
+	}
+
+	return append(keys, field)
+}
@@ -0,0 +1,411 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testTeamsConfig = `teams:
+  team-abc:
+    description: Contributors who can use commands on
+      issues/PRs
+    members:
+    - alice # 1.33 Comms Shadow
+    # pending membership
+    # - carol
+    - dave
+    privacy: closed
+    teams:
+      child:
+        maintainers:
+        - erin
+        privacy: closed
+  team-empty:
+    # intentionally empty
+    members: []
+    privacy: closed
+`
+
+func writeTestConfig(t *testing.T, contents string) *configFile {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "teams.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	f, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	return f
+}
+
+func TestAddToList(t *testing.T) {
+	cases := []struct {
+		desc     string
+		value    string
+		keys     []string
+		expected string
+	}{
+		{
+			desc:  "in sorted position before a comment",
+			value: "Bob",
+			keys:  []string{"teams", "team-abc", "members"},
+			expected: `teams:
+  team-abc:
+    description: Contributors who can use commands on
+      issues/PRs
+    members:
+    - alice # 1.33 Comms Shadow
+    - Bob
+    # pending membership
+    # - carol
+    - dave
+    privacy: closed
+    teams:
+      child:
+        maintainers:
+        - erin
+        privacy: closed
+  team-empty:
+    # intentionally empty
+    members: []
+    privacy: closed
+`,
+		},
+		{
+			desc:  "at the end of the list",
+			value: "zed",
+			keys:  []string{"teams", "team-abc", "members"},
+			expected: `teams:
+  team-abc:
+    description: Contributors who can use commands on
+      issues/PRs
+    members:
+    - alice # 1.33 Comms Shadow
+    # pending membership
+    # - carol
+    - dave
+    - zed
+    privacy: closed
+    teams:
+      child:
+        maintainers:
+        - erin
+        privacy: closed
+  team-empty:
+    # intentionally empty
+    members: []
+    privacy: closed
+`,
+		},
+		{
+			desc:  "to a missing list of a child team",
+			value: "frank",
+			keys:  []string{"teams", "team-abc", "teams", "child", "members"},
+			expected: `teams:
+  team-abc:
+    description: Contributors who can use commands on
+      issues/PRs
+    members:
+    - alice # 1.33 Comms Shadow
+    # pending membership
+    # - carol
+    - dave
+    privacy: closed
+    teams:
+      child:
+        maintainers:
+        - erin
+        members:
+        - frank
+        privacy: closed
+  team-empty:
+    # intentionally empty
+    members: []
+    privacy: closed
+`,
+		},
+		{
+			desc:  "to an empty flow list, quoting numeric values",
+			value: "12345",
+			keys:  []string{"teams", "team-empty", "members"},
+			expected: `teams:
+  team-abc:
+    description: Contributors who can use commands on
+      issues/PRs
+    members:
+    - alice # 1.33 Comms Shadow
+    # pending membership
+    # - carol
+    - dave
+    privacy: closed
+    teams:
+      child:
+        maintainers:
+        - erin
+        privacy: closed
+  team-empty:
+    # intentionally empty
+    members:
+    - "12345"
+    privacy: closed
+`,
+		},
+	}
+
+	for _, c := range cases {
+		f := writeTestConfig(t, testTeamsConfig)
+		added, err := f.addToList(c.value, c.keys...)
+		if err != nil {
+			t.Errorf("unexpected error adding %s: %v", c.desc, err)
+			continue
+		}
+		if !added {
+			t.Errorf("expected %s to be added %s", c.value, c.desc)
+		}
+		if err := f.save(); err != nil {
+			t.Fatalf("saving config: %v", err)
+		}
+
+		got, err := os.ReadFile(f.path)
+		if err != nil {
+			t.Fatalf("reading config: %v", err)
+		}
+		if string(got) != c.expected {
+			t.Errorf("unexpected config after adding %s:\n%s\nexpected:\n%s", c.desc, got, c.expected)
+		}
+	}
+}
+
+func TestAddToListNull(t *testing.T) {
+	cases := []struct {
+		desc     string
+		config   string
+		expected string
+	}{
+		{
+			desc:     "key without a value",
+			config:   "teams:\n  foo:\n    members:\n    privacy: closed\n",
+			expected: "teams:\n  foo:\n    members:\n    - bob\n    privacy: closed\n",
+		},
+		{
+			desc:     "null",
+			config:   "teams:\n  foo:\n    members: null\n    privacy: closed\n",
+			expected: "teams:\n  foo:\n    members:\n    - bob\n    privacy: closed\n",
+		},
+		{
+			desc:     "tilde with a comment",
+			config:   "teams:\n  foo:\n    members: ~ # none yet\n    privacy: closed\n",
+			expected: "teams:\n  foo:\n    members: # none yet\n    - bob\n    privacy: closed\n",
+		},
+	}
+
+	for _, c := range cases {
+		f := writeTestConfig(t, c.config)
+		if _, err := f.addToList("bob", "teams", "foo", "members"); err != nil {
+			t.Errorf("unexpected error adding to %s: %v", c.desc, err)
+			continue
+		}
+		if got := strings.Join(f.lines, "\n"); got != c.expected {
+			t.Errorf("unexpected config after adding to %s:\n%s\nexpected:\n%s", c.desc, got, c.expected)
+		}
+		if _, err := f.decode(); err != nil {
+			t.Errorf("invalid config after adding to %s: %v", c.desc, err)
+		}
+	}
+}
+
+func TestAddToListExisting(t *testing.T) {
+	f := writeTestConfig(t, testTeamsConfig)
+	added, err := f.addToList("ALICE", "teams", "team-abc", "members")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if added {
+		t.Errorf("expected existing member not to be added again")
+	}
+}
+
+func TestRemoveFromList(t *testing.T) {
+	cases := []struct {
+		desc     string
+		value    string
+		keys     []string
+		removed  bool
+		expected string
+	}{
+		{
+			desc:    "a commented item, case-agnostically",
+			value:   "Alice",
+			keys:    []string{"teams", "team-abc", "members"},
+			removed: true,
+			expected: `teams:
+  team-abc:
+    description: Contributors who can use commands on
+      issues/PRs
+    members:
+    # pending membership
+    # - carol
+    - dave
+    privacy: closed
+    teams:
+      child:
+        maintainers:
+        - erin
+        privacy: closed
+  team-empty:
+    # intentionally empty
+    members: []
+    privacy: closed
+`,
+		},
+		{
+			desc:    "the last item of a list along with its key",
+			value:   "erin",
+			keys:    []string{"teams", "team-abc", "teams", "child", "maintainers"},
+			removed: true,
+			expected: `teams:
+  team-abc:
+    description: Contributors who can use commands on
+      issues/PRs
+    members:
+    - alice # 1.33 Comms Shadow
+    # pending membership
+    # - carol
+    - dave
+    privacy: closed
+    teams:
+      child:
+        privacy: closed
+  team-empty:
+    # intentionally empty
+    members: []
+    privacy: closed
+`,
+		},
+		{
+			desc:    "an item along with the comments preceding it",
+			value:   "dave",
+			keys:    []string{"teams", "team-abc", "members"},
+			removed: true,
+			expected: `teams:
+  team-abc:
+    description: Contributors who can use commands on
+      issues/PRs
+    members:
+    - alice # 1.33 Comms Shadow
+    privacy: closed
+    teams:
+      child:
+        maintainers:
+        - erin
+        privacy: closed
+  team-empty:
+    # intentionally empty
+    members: []
+    privacy: closed
+`,
+		},
+		{
+			desc:     "a missing item",
+			value:    "carol",
+			keys:     []string{"teams", "team-abc", "members"},
+			removed:  false,
+			expected: testTeamsConfig,
+		},
+	}
+
+	for _, c := range cases {
+		f := writeTestConfig(t, testTeamsConfig)
+		removed, err := f.removeFromList(c.value, c.keys...)
+		if err != nil {
+			t.Errorf("unexpected error removing %s: %v", c.desc, err)
+			continue
+		}
+		if removed != c.removed {
+			t.Errorf("expected removed to be %v when removing %s, got %v", c.removed, c.desc, removed)
+		}
+		if err := f.save(); err != nil {
+			t.Fatalf("saving config: %v", err)
+		}
+
+		got, err := os.ReadFile(f.path)
+		if err != nil {
+			t.Fatalf("reading config: %v", err)
+		}
+		if string(got) != c.expected {
+			t.Errorf("unexpected config after removing %s:\n%s\nexpected:\n%s", c.desc, got, c.expected)
+		}
+	}
+}
+
+func TestEmptyFlowMapping(t *testing.T) {
+	cases := []struct {
+		desc     string
+		config   string
+		edit     func(f *configFile) error
+		expected string
+	}{
+		{
+			desc:   "removing the only member of a team",
+			config: "teams:\n  bar:\n    privacy: closed\n  foo: # new\n    # lead\n    members:\n    - bob\n",
+			edit: func(f *configFile) error {
+				_, err := f.removeFromList("bob", "teams", "foo", "members")
+				return err
+			},
+			expected: "teams:\n  bar:\n    privacy: closed\n  foo: {} # new\n",
+		},
+		{
+			desc:   "adding a member to an empty team",
+			config: "teams:\n  foo: {} # new\n  zed:\n    privacy: closed\n",
+			edit: func(f *configFile) error {
+				_, err := f.addToList("bob", "teams", "foo", "members")
+				return err
+			},
+			expected: "teams:\n  foo: # new\n    members:\n    - bob\n  zed:\n    privacy: closed\n",
+		},
+		{
+			desc:   "setting the privacy of an empty team",
+			config: "teams:\n  foo: {}\n",
+			edit: func(f *configFile) error {
+				_, err := f.setScalar("closed", "teams", "foo", "privacy")
+				return err
+			},
+			expected: "teams:\n  foo:\n    privacy: closed\n",
+		},
+	}
+
+	for _, c := range cases {
+		f := writeTestConfig(t, c.config)
+		if err := c.edit(f); err != nil {
+			t.Errorf("unexpected error %s: %v", c.desc, err)
+			continue
+		}
+		if got := strings.Join(f.lines, "\n"); got != c.expected {
+			t.Errorf("unexpected config after %s:\n%s\nexpected:\n%s", c.desc, got, c.expected)
+		}
+		if _, err := f.decode(); err != nil {
+			t.Errorf("invalid config after %s: %v", c.desc, err)
+		}
+	}
+}
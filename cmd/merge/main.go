@@ -17,14 +17,19 @@ limitations under the License.
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"sigs.k8s.io/prow/pkg/config/org"
 
+	"k8s.io/org/pkg/orgconfig"
+
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
 )
@@ -60,17 +65,26 @@ func (fm flagMap) Set(s string) error {
 	return nil
 }
 
+const (
+	formatYAML = "yaml"
+	formatJSON = "json"
+)
+
 type options struct {
 	orgs        flagMap
 	mergeTeams  bool
 	ignoreTeams bool
+	output      string
+	format      string
 }
 
 func main() {
 	o := options{orgs: flagMap{}}
 	flag.Var(o.orgs, "org-part", "Each instance adds an org-name=org.yaml part")
-	flag.BoolVar(&o.mergeTeams, "merge-teams", false, "Merge team-name/team.yaml files in each org.yaml dir")
+	flag.BoolVar(&o.mergeTeams, "merge-teams", false, "Merge the teams.yaml files below each org.yaml dir, at any depth. The repos.yaml and members.d/*.yaml files below it are always merged")
 	flag.BoolVar(&o.ignoreTeams, "ignore-teams", false, "Never configure teams")
+	flag.StringVar(&o.output, "output", "", "Path to write the merged config to, atomically, instead of stdout")
+	flag.StringVar(&o.format, "format", formatYAML, "Format of the merged config, yaml or json")
 	flag.Parse()
 
 	for _, a := range flag.Args() {
@@ -81,77 +95,94 @@ func main() {
 	if o.mergeTeams && o.ignoreTeams {
 		logrus.Fatal("--merge-teams xor --ignore-teams, not both")
 	}
+	if o.format != formatYAML && o.format != formatJSON {
+		logrus.Fatalf("--format must be %s or %s, not %s", formatYAML, formatJSON, o.format)
+	}
 
 	cfg, err := loadOrgs(o)
 	if err != nil {
 		logrus.Fatalf("Failed to load orgs: %v", err)
 	}
-	pc := org.FullConfig{
-		Orgs: cfg,
-	}
-	out, err := yaml.Marshal(pc)
+	out, err := render(org.FullConfig{Orgs: cfg}, o.format)
 	if err != nil {
-		logrus.Fatalf("Failed to marshal orgs: %v", err)
+		logrus.Fatalf("Failed to render orgs: %v", err)
+	}
+	if o.output == "" {
+		if _, err := os.Stdout.Write(out); err != nil {
+			logrus.Fatalf("Failed to write orgs: %v", err)
+		}
+		return
+	}
+	if err := writeFile(o.output, out); err != nil {
+		logrus.Fatalf("Failed to write orgs: %v", err)
 	}
-	fmt.Println(string(out))
 }
 
-func unmarshalFromFile(path string) (*org.Config, error) {
-	buf, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read: %v", err)
+// render marshals cfg in format. The output only depends on cfg: map keys are
+// sorted. YAML output starts with a header holding the SHA-256 of the rest of
+// it, JSON has no room for one.
+func render(cfg org.FullConfig, format string) ([]byte, error) {
+	if format == formatJSON {
+		out, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
 	}
 
-	return unmarshal(buf)
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf("# Code generated by cmd/merge. DO NOT EDIT.\n# sha256: %x\n", sha256.Sum256(out))
+	return append([]byte(header), out...), nil
 }
 
-func unmarshal(buf []byte) (*org.Config, error) {
-	var cfg org.Config
-	if err := yaml.Unmarshal(buf, &cfg, yaml.DisallowUnknownFields); err != nil {
-		return nil, fmt.Errorf("unmarshal: %v", err)
+// writeFile writes data to path atomically: readers see either the previous
+// contents or data, never a partial write.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
 	}
-	return &cfg, nil
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 func loadOrgs(o options) (map[string]org.Config, error) {
+	names := make([]string, 0, len(o.orgs))
+	for name := range o.orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	config := map[string]org.Config{}
-	for name, path := range o.orgs {
-		cfg, err := unmarshalFromFile(path)
+	for _, name := range names {
+		path := o.orgs[name]
+		load := orgconfig.LoadOrgWithoutTeamsFiles
+		if o.mergeTeams {
+			load = orgconfig.LoadOrg
+		}
+		loaded, err := load(path)
 		if err != nil {
-			return nil, fmt.Errorf("error in %s: %v", path, err)
+			return nil, err
 		}
-		switch {
-		case o.ignoreTeams:
+		cfg := &loaded.Config
+
+		if o.ignoreTeams {
 			cfg.Teams = nil
-		case o.mergeTeams:
-			if cfg.Teams == nil {
-				cfg.Teams = map[string]org.Team{}
-			}
-			prefix := filepath.Dir(path)
-			err := filepath.Walk(prefix, func(path string, info os.FileInfo, err error) error {
-				switch {
-				case path == prefix:
-					return nil // Skip base dir
-				case info.IsDir() && filepath.Dir(path) != prefix:
-					logrus.Infof("Skipping %s and its children", path)
-					return filepath.SkipDir // Skip prefix/foo/bar/ dirs
-				case !info.IsDir() && filepath.Dir(path) == prefix:
-					return nil // Ignore prefix/foo files
-				case filepath.Base(path) == "teams.yaml":
-					teamCfg, err := unmarshalFromFile(path)
-					if err != nil {
-						return fmt.Errorf("error in %s: %v", path, err)
-					}
-
-					for name, team := range teamCfg.Teams {
-						cfg.Teams[name] = team
-					}
-				}
-				return nil
-			})
-			if err != nil {
-				return nil, fmt.Errorf("merge teams %s: %v", path, err)
-			}
 		}
 		config[name] = *cfg
 	}
@@ -18,8 +18,20 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+
+	"k8s.io/org/internal/testutil"
+	"k8s.io/org/pkg/orgconfig"
 )
 
 const testOrgConfig = `admins:
@@ -64,7 +76,7 @@ func TestStrictUnmarshalling(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		_, err := unmarshal(
+		_, err := orgconfig.Unmarshal(
 			bytes.NewBufferString(fmt.Sprintf(testOrgConfig, c.repoKey)).Bytes(),
 		)
 		if !c.expectError && err != nil {
@@ -75,3 +87,267 @@ func TestStrictUnmarshalling(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadOrgsMergeTeams(t *testing.T) {
+	cases := []struct {
+		desc        string
+		files       map[string]string
+		overrides   string
+		expected    []string
+		kept        map[string]string
+		expectError string
+	}{
+		{
+			desc: "nested team directories",
+			files: map[string]string{
+				"org.yaml":                                "teams:\n  top:\n    privacy: closed\n",
+				"sig-release/teams.yaml":                  "teams:\n  release:\n    privacy: closed\n",
+				"sig-release/release-team/teams.yaml":     "teams:\n  release-team:\n    privacy: closed\n",
+				"sig-release/release-team/a/b/teams.yaml": "teams:\n  deep:\n    privacy: closed\n",
+				"sig-release/README.md":                   "not a config",
+			},
+			expected: []string{"deep", "release", "release-team", "top"},
+		},
+		{
+			desc: "team in org.yaml and a teams.yaml",
+			files: map[string]string{
+				"org.yaml":            "teams:\n  dup:\n    privacy: closed\n",
+				"sig-foo/teams.yaml":  "teams:\n  dup:\n    privacy: closed\n",
+				"sig-bar/teams.yaml":  "teams:\n  bar:\n    privacy: closed\n",
+				"sig-bar/x/foo.yaml":  "teams:\n  dup:\n    privacy: closed\n",
+				"sig-bar/teams.yml":   "teams:\n  dup:\n    privacy: closed\n",
+				"sig-bar/x/teams.txt": "teams:\n  dup:\n    privacy: closed\n",
+			},
+			expectError: "team dup is defined in both %[1]s/org.yaml:2 and %[1]s/sig-foo/teams.yaml:2",
+		},
+		{
+			desc: "team in two nested teams.yaml",
+			files: map[string]string{
+				"org.yaml":                      "name: Org\n",
+				"sig-foo/teams.yaml":            "teams:\n  dup:\n    privacy: closed\n",
+				"sig-bar/subproject/teams.yaml": "teams:\n  dup:\n    privacy: closed\n",
+			},
+			expectError: "team dup is defined in both %[1]s/sig-bar/subproject/teams.yaml:2 and %[1]s/sig-foo/teams.yaml:2",
+		},
+		{
+			desc: "child team named like a top-level team",
+			files: map[string]string{
+				"org.yaml":           "teams:\n  parent:\n    teams:\n      dup:\n        privacy: closed\n",
+				"sig-foo/teams.yaml": "teams:\n  Dup:\n    privacy: closed\n",
+			},
+			expectError: "team Dup is defined in both %[1]s/org.yaml:4 and %[1]s/sig-foo/teams.yaml:2",
+		},
+		{
+			desc: "child teams of different parents with the same name",
+			files: map[string]string{
+				"org.yaml":           "name: Org\n",
+				"sig-foo/teams.yaml": "teams:\n  a:\n    teams:\n      dup: {}\n  b:\n    teams:\n      dup: {}\n",
+			},
+			expectError: "team dup is defined in both %[1]s/sig-foo/teams.yaml:4 and %[1]s/sig-foo/teams.yaml:7",
+		},
+		{
+			desc: "overridden team",
+			files: map[string]string{
+				"org.yaml":           "teams:\n  dup:\n    description: org\n  top: {}\n",
+				"sig-foo/teams.yaml": "teams:\n  dup:\n    description: sig-foo\n    teams:\n      child: {}\n",
+				"sig-bar/teams.yaml": "teams:\n  dup:\n    description: sig-bar\n",
+			},
+			overrides: "orgs:\n  org:\n    teams:\n      dup: sig-foo/teams.yaml\n",
+			expected:  []string{"dup", "top"},
+			kept:      map[string]string{"dup": "sig-foo"},
+		},
+		{
+			desc: "override of a team defined once",
+			files: map[string]string{
+				"org.yaml":           "name: Org\n",
+				"sig-foo/teams.yaml": "teams:\n  foo: {}\n",
+			},
+			overrides:   "orgs:\n  org:\n    teams:\n      foo: sig-foo/teams.yaml\n",
+			expectError: "team foo is overridden but only defined in %[1]s/sig-foo/teams.yaml:2",
+		},
+		{
+			desc: "override by a file not defining the team",
+			files: map[string]string{
+				"org.yaml":           "teams:\n  dup: {}\n",
+				"sig-foo/teams.yaml": "teams:\n  dup: {}\n",
+			},
+			overrides:   "orgs:\n  org:\n    teams:\n      dup: sig-bar/teams.yaml\n",
+			expectError: "team dup is overridden by %[1]s/sig-bar/teams.yaml, which doesn't define it",
+		},
+		{
+			desc: "unknown field in a nested teams.yaml",
+			files: map[string]string{
+				"org.yaml":             "name: Org\n",
+				"sig-foo/x/teams.yaml": "teams:\n  foo:\n    privacy: closed\n    typo: true\n",
+			},
+			expectError: "error in %[1]s/sig-foo/x/teams.yaml: unmarshal: error unmarshaling JSON: while decoding JSON: json: unknown field \"typo\"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			files := map[string]string{}
+			for name, contents := range c.files {
+				files[filepath.Join("org", name)] = contents
+			}
+			if c.overrides != "" {
+				files[orgconfig.OverridesFile] = c.overrides
+			}
+			dir := filepath.Join(testutil.WriteFiles(t, files), "org")
+			o := options{orgs: flagMap{"org": filepath.Join(dir, "org.yaml")}, mergeTeams: true}
+
+			cfg, err := loadOrgs(o)
+			if c.expectError != "" {
+				if expected := fmt.Sprintf(c.expectError, dir); err == nil || err.Error() != expected {
+					t.Fatalf("expected error %q, got %v", expected, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			teams := []string{}
+			for name := range cfg["org"].Teams {
+				teams = append(teams, name)
+			}
+			sort.Strings(teams)
+			if !reflect.DeepEqual(teams, c.expected) {
+				t.Errorf("unexpected teams: %v, expected %v", teams, c.expected)
+			}
+			for name, description := range c.kept {
+				if got := cfg["org"].Teams[name].Description; got == nil || *got != description {
+					t.Errorf("team %s: kept the wrong definition, description %v, expected %s", name, got, description)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadOrgsFragments(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"org/org.yaml":               "admins:\n- admin1\nmembers:\n- user1\nteams:\n  top: {}\n",
+		"org/members.d/sig-foo.yaml": "members:\n- user2\n",
+		"org/sig-foo/repos.yaml":     "repos:\n  foo:\n    description: Foo\n",
+		"org/sig-foo/teams.yaml":     "teams:\n  foo: {}\n",
+	})
+
+	cases := []struct {
+		desc     string
+		o        options
+		expected []string
+	}{
+		{
+			desc:     "merging teams",
+			o:        options{mergeTeams: true},
+			expected: []string{"foo", "top"},
+		},
+		{
+			desc:     "teams of org.yaml only",
+			o:        options{},
+			expected: []string{"top"},
+		},
+		{
+			desc:     "ignoring teams",
+			o:        options{ignoreTeams: true},
+			expected: []string{},
+		},
+	}
+
+	for _, c := range cases {
+		c.o.orgs = flagMap{"org": filepath.Join(root, "org/org.yaml")}
+		cfg, err := loadOrgs(c.o)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+			continue
+		}
+
+		if members := cfg["org"].Members; !reflect.DeepEqual(members, []string{"user1", "user2"}) {
+			t.Errorf("%s: unexpected members %v", c.desc, members)
+		}
+		if _, found := cfg["org"].Repos["foo"]; !found {
+			t.Errorf("%s: missing repo foo of repos.yaml", c.desc)
+		}
+		teams := []string{}
+		for name := range cfg["org"].Teams {
+			teams = append(teams, name)
+		}
+		sort.Strings(teams)
+		if !reflect.DeepEqual(teams, c.expected) {
+			t.Errorf("%s: unexpected teams %v, expected %v", c.desc, teams, c.expected)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"a/org.yaml":           "admins:\n- admin1\nmembers:\n- user1\n",
+		"a/sig-foo/teams.yaml": "teams:\n  foo:\n    members:\n    - user1\n  bar: {}\n",
+		"b/org.yaml":           "name: B\n",
+	})
+	o := options{orgs: flagMap{"a": filepath.Join(root, "a/org.yaml"), "b": filepath.Join(root, "b/org.yaml")}, mergeTeams: true}
+
+	for _, format := range []string{formatYAML, formatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var outputs [][]byte
+			for i := 0; i < 5; i++ {
+				cfg, err := loadOrgs(o)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				out, err := render(org.FullConfig{Orgs: cfg}, format)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				outputs = append(outputs, out)
+			}
+			for _, out := range outputs[1:] {
+				if !bytes.Equal(out, outputs[0]) {
+					t.Fatalf("output differs between runs:\n%s\n---\n%s", outputs[0], out)
+				}
+			}
+
+			out := outputs[0]
+			if format == formatJSON {
+				var cfg org.FullConfig
+				if err := json.Unmarshal(out, &cfg); err != nil || len(cfg.Orgs) != 2 {
+					t.Errorf("unexpected JSON output, error %v: %s", err, out)
+				}
+				return
+			}
+
+			lines := strings.SplitN(string(out), "\n", 3)
+			if expected := fmt.Sprintf("# sha256: %x", sha256.Sum256([]byte(lines[2]))); lines[1] != expected {
+				t.Errorf("unexpected hash header %q, expected %q", lines[1], expected)
+			}
+			if !strings.HasPrefix(lines[2], "orgs:\n") {
+				t.Errorf("unexpected YAML output: %s", out)
+			}
+		})
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gen-config.yaml")
+	for _, contents := range []string{"first\n", "second\n"} {
+		if err := writeFile(path, []byte(contents)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != contents {
+			t.Errorf("unexpected contents %q, expected %q", got, contents)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the output file to be left, found %d files", len(entries))
+	}
+}
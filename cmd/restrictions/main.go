@@ -21,10 +21,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
+	"sort"
 
 	"k8s.io/org/cmd/helpers"
+	"k8s.io/org/pkg/orgconfig"
 
 	"github.com/bmatcuk/doublestar"
 	"github.com/ghodss/yaml"
@@ -76,28 +77,15 @@ func main() {
 	var restrictionViolated bool
 	for name, path := range o.orgs {
 		logrus.Infof("Validating restrictions for %s org", name)
-		prefix := filepath.Dir(path)
-		err := filepath.Walk(prefix, func(path string, info os.FileInfo, err error) error {
-			switch {
-			case path == prefix:
-				return nil // Skip base dir
-			case info.IsDir() && filepath.Dir(path) != prefix:
-				logrus.Infof("Skipping %s and its children", path)
-				return filepath.SkipDir // Skip prefix/foo/bar/ dirs
-			case !info.IsDir() && filepath.Dir(path) == prefix && filepath.Base(path) != "org.yaml":
-				return nil // Ignore prefix/foo files
-			case filepath.Base(path) == "teams.yaml" || filepath.Base(path) == "org.yaml":
-				if err := resolveRestriction(restrictions, path); err != nil {
-					if errors.Is(err, errRestrictionViolation) {
-						restrictionViolated = true
-					}
-					logrus.Error(err)
-				}
-			}
-			return nil
-		})
+		org, err := orgconfig.LoadOrg(path)
 		if err != nil {
-			logrus.Fatalf("Failed to walk through files at %s", path)
+			logrus.Fatalf("Failed to load %s org: %v", name, err)
+		}
+		if err := resolveRestriction(restrictions, org); err != nil {
+			if errors.Is(err, errRestrictionViolation) {
+				restrictionViolated = true
+			}
+			logrus.Error(err)
 		}
 	}
 	if restrictionViolated {
@@ -133,19 +121,33 @@ func compileRegexps(restrictions []Restriction) ([]Restriction, error) {
 	return ret, nil
 }
 
-func resolveRestriction(restrictions []Restriction, path string) error {
-	orgCfg, err := helpers.UnmarshalPathToOrgConfig(path)
-	if err != nil {
-		return fmt.Errorf("error in unmarshalling path %s: %v", path, err)
+// resolveRestriction checks the repos of each top-level team of org against
+// the restriction of the file defining the team.
+func resolveRestriction(restrictions []Restriction, org *orgconfig.Org) error {
+	teamNames := make([]string, 0, len(org.Config.Teams))
+	for teamName := range org.Config.Teams {
+		teamNames = append(teamNames, teamName)
 	}
-	r := getRestrictionForPath(restrictions, path)
+	sort.Strings(teamNames)
 
 	var err2 error
-	for teamName, team := range orgCfg.Teams {
-		for repo := range team.Repos {
+	for _, teamName := range teamNames {
+		teamSource, _ := org.TeamSource(teamName)
+		r := getRestrictionForPath(restrictions, teamSource.File)
+
+		repos := make([]string, 0, len(org.Config.Teams[teamName].Repos))
+		for repo := range org.Config.Teams[teamName].Repos {
+			repos = append(repos, repo)
+		}
+		sort.Strings(repos)
+
+		for _, repo := range repos {
 			if !matchesRegexList(repo, r.AllowedReposRe) {
-				err2 = errRestrictionViolation
-				err2 = fmt.Errorf("%w\n%q: cannot define repo %q for team %q", err2, path, repo, teamName)
+				if err2 == nil {
+					err2 = errRestrictionViolation
+				}
+				source, _ := org.TeamRepoSource(teamName, repo)
+				err2 = fmt.Errorf("%w\n%s: cannot define repo %q for team %q", err2, source, repo, teamName)
 			}
 		}
 	}
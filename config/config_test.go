@@ -19,15 +19,15 @@ package config
 import (
 	"fmt"
 	"os"
-	"sort"
 	"strings"
 	"testing"
 
-	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/prow/pkg/config/org"
-	"sigs.k8s.io/prow/pkg/github"
 
 	"github.com/ghodss/yaml"
+
+	"k8s.io/org/pkg/lint"
+	"k8s.io/org/pkg/orgconfig"
 )
 
 var cfg org.FullConfig
@@ -53,165 +53,7 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
-type owners struct {
-	Reviewers []string `json:"reviewers,omitempty"`
-	Approvers []string `json:"approvers"`
-}
-
-func readInto(path string, i interface{}) error {
-	buf, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("read: %v", err)
-	}
-	if err := yaml.Unmarshal(buf, i); err != nil {
-		return fmt.Errorf("unmarshal: %v", err)
-	}
-	return nil
-}
-
-func loadOwners(dir string) (*owners, error) {
-	var own owners
-	if err := readInto(dir+"/OWNERS", &own); err != nil {
-		return nil, err
-	}
-	return &own, nil
-}
-
-func loadOrg(dir string) (*org.Config, error) {
-	var cfg org.Config
-	if err := readInto(dir+"/org.yaml", &cfg); err != nil {
-		return nil, err
-	}
-	return &cfg, nil
-}
-
-func testDuplicates(list sets.String) error {
-	found := sets.String{}
-	dups := sets.String{}
-	all := list.List()
-	for _, i := range all {
-		if found.Has(i) {
-			dups.Insert(i)
-		}
-		found.Insert(i)
-	}
-	if n := len(dups); n > 0 {
-		return fmt.Errorf("%d duplicate names: %s", n, strings.Join(dups.List(), ", "))
-	}
-	return nil
-}
-
-func isSorted(list []string) bool {
-	items := make([]string, len(list))
-	for _, l := range list {
-		items = append(items, strings.ToLower(l))
-	}
-
-	return sort.StringsAreSorted(items)
-}
-
-func normalize(s sets.String) sets.String {
-	out := sets.String{}
-	for i := range s {
-		out.Insert(github.NormLogin(i))
-	}
-	return out
-}
-
-// testTeamMembers ensures that a user is not a maintainer and member at the same time,
-// there are no duplicate names in the list and all users are org members.
-func testTeamMembers(teams map[string]org.Team, admins sets.String, orgMembers sets.String, orgName string) []error {
-	var errs []error
-	for teamName, team := range teams {
-		teamMaintainers := sets.NewString(team.Maintainers...)
-		teamMembers := sets.NewString(team.Members...)
-
-		teamMaintainers = normalize(teamMaintainers)
-		teamMembers = normalize(teamMembers)
-
-		// ensure all teams have privacy as closed
-		if team.Privacy == nil || (team.Privacy != nil && *team.Privacy != org.Closed) {
-			errs = append(errs, fmt.Errorf("The team %s in org %s doesn't have the `privacy: closed` field", teamName, orgName))
-		}
-
-		// check for non-admins in maintainers list
-		if nonAdminMaintainers := teamMaintainers.Difference(admins); len(nonAdminMaintainers) > 0 {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has non-admins listed as maintainers; these users should be in the members list instead: %s", teamName, orgName, strings.Join(nonAdminMaintainers.List(), ",")))
-		}
-
-		// check for users in both maintainers and members
-		if both := teamMaintainers.Intersection(teamMembers); len(both) > 0 {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has users in both maintainer admin and member roles: %s", teamName, orgName, strings.Join(both.List(), ", ")))
-		}
-
-		// check for duplicates
-		if err := testDuplicates(teamMaintainers); err != nil {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has duplicate maintainers: %v", teamName, orgName, err))
-		}
-		if err := testDuplicates(teamMembers); err != nil {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has duplicate members: %v", teamMembers, orgName, err))
-		}
-
-		// check if all are org members
-		if missing := teamMembers.Difference(orgMembers); len(missing) > 0 {
-			errs = append(errs, fmt.Errorf("The following members of team %s are not %s org members: %s", teamName, orgName, strings.Join(missing.List(), ", ")))
-		}
-
-		// check if admins are a regular member of team
-		if adminTeamMembers := teamMembers.Intersection(admins); len(adminTeamMembers) > 0 {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has org admins listed as members; these users should be in the maintainers list instead, and cannot be on the members list: %s", teamName, orgName, strings.Join(adminTeamMembers.List(), ", ")))
-		}
-
-		// check if lists are sorted
-		if !isSorted(team.Maintainers) {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has an unsorted list of maintainers", teamName, orgName))
-		}
-		if !isSorted(team.Members) {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has an unsorted list of members", teamName, orgName))
-		}
-
-		if team.Children != nil {
-			errs = append(errs, testTeamMembers(team.Children, admins, orgMembers, orgName)...)
-		}
-	}
-	return errs
-}
-
-func testOrg(targetDir string, t *testing.T) {
-	cfg, err := loadOrg(targetDir)
-	if err != nil {
-		t.Fatalf("failed to load org.yaml: %v", err)
-	}
-	own, err := loadOwners(targetDir)
-	if err != nil {
-		t.Fatalf("failed to load OWNERS: %v", err)
-	}
-
-	members := normalize(sets.NewString(cfg.Members...))
-	admins := normalize(sets.NewString(cfg.Admins...))
-	allOrgMembers := members.Union(admins)
-
-	reviewers := normalize(sets.NewString(own.Reviewers...))
-	approvers := normalize(sets.NewString(own.Approvers...))
-
-	if n := len(approvers); n < 5 {
-		t.Errorf("Require at least 5 approvers, found %d: %s", n, strings.Join(approvers.List(), ", "))
-	}
-
-	if missing := reviewers.Difference(allOrgMembers); len(missing) > 0 {
-		t.Errorf("The following reviewers must be members: %s", strings.Join(missing.List(), ", "))
-	}
-	if missing := approvers.Difference(allOrgMembers); len(missing) > 0 {
-		t.Errorf("The following approvers must be members: %s", strings.Join(missing.List(), ", "))
-	}
-	if err := testDuplicates(reviewers); err != nil {
-		t.Errorf("duplicate reviewers: %v", err)
-	}
-	if err := testDuplicates(approvers); err != nil {
-		t.Errorf("duplicate approvers: %v", err)
-	}
-}
-
+// TestAllOrgs checks every org against the rules of korg lint.
 func TestAllOrgs(t *testing.T) {
 	f, err := os.Open(".")
 	if err != nil {
@@ -233,41 +75,38 @@ func TestAllOrgs(t *testing.T) {
 			if _, ok := cfg.Orgs[n]; !ok {
 				t.Errorf("%s missing from generated config.yaml", n)
 			}
-			testOrg(n, t)
+
+			o, err := lint.LoadOrg(n)
+			if err != nil {
+				t.Fatalf("failed to load org: %v", err)
+			}
+			for _, f := range lint.Check(o) {
+				if f.Severity == lint.SeverityError {
+					t.Error(f)
+				} else {
+					t.Log(f)
+				}
+			}
 		})
 	}
+}
 
-	for _, org := range cfg.Orgs {
-		members := normalize(sets.NewString(org.Members...))
-		admins := normalize(sets.NewString(org.Admins...))
-		allOrgMembers := members.Union(admins)
-
-		if both := admins.Intersection(members); len(both) > 0 {
-			t.Errorf("users in both org admin and member roles for org '%s': %s", *org.Name, strings.Join(both.List(), ", "))
-		}
-
-		if !admins.Has("k8s-ci-robot") {
-			t.Errorf("k8s-ci-robot must be an admin")
-		}
+// TestMergedConfig makes sure the merged config holds exactly the orgs of the
+// repo.
+func TestMergedConfig(t *testing.T) {
+	repo, err := orgconfig.Load(".")
+	if err != nil {
+		t.Fatalf("cannot load config: %v", err)
+	}
 
-		if err := testDuplicates(admins); err != nil {
-			t.Errorf("duplicate admins: %v", err)
-		}
-		if err := testDuplicates(allOrgMembers); err != nil {
-			t.Errorf("duplicate members: %v", err)
-		}
-		if !isSorted(org.Admins) {
-			t.Errorf("admins for %s org are unsorted", *org.Name)
+	for _, name := range repo.Names() {
+		if _, ok := cfg.Orgs[name]; !ok {
+			t.Errorf("%s missing from generated config.yaml", name)
 		}
-		if !isSorted(org.Members) {
-			t.Errorf("members for %s org are unsorted", *org.Name)
-		}
-
-		if errs := testTeamMembers(org.Teams, admins, allOrgMembers, *org.Name); errs != nil {
-			for _, err := range errs {
-				t.Error(err)
-			}
+	}
+	for name := range cfg.Orgs {
+		if _, ok := repo.Orgs[name]; !ok {
+			t.Errorf("%s in generated config.yaml is not configured in the repo", name)
 		}
-
 	}
 }
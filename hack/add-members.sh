@@ -29,13 +29,10 @@ fi
 REPOS=${REPOS:-"kubernetes"}
 
 cd "$SCRIPT_ROOT"
-for username in ${WHO//,/ }
-do
-    echo "Adding $username to $REPOS"
-   if [ "$DRY_RUN" = true ]; then
-     echo "Running in dry run mode."
-     go run ./cmd/korg add "$username" --org "$REPOS"
-   else
-     go run ./cmd/korg add "$username" --org "$REPOS" --confirm
-   fi
-done
+echo "Adding ${WHO//,/ } to $REPOS"
+if [ "$DRY_RUN" = true ]; then
+  echo "Running in dry run mode."
+  go run ./cmd/korg add ${WHO//,/ } --org "$REPOS"
+else
+  go run ./cmd/korg add ${WHO//,/ } --org "$REPOS" --confirm
+fi
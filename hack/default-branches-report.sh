@@ -14,13 +14,14 @@
 # See the License for the specific language governing permissions and
 # limitations under the License.
 
-readonly kubernetes_orgs=(
-    etcd-io
-    kubernetes
-    kubernetes-sigs
-    kubernetes-client
-    kubernetes-csi
-)
+readonly REPO_ROOT=$(dirname "${BASH_SOURCE[0]}")/..
+
+# orgs are discovered from config/<org>/org.yaml, same as korg
+kubernetes_orgs=()
+for org_config in "${REPO_ROOT}"/config/*/org.yaml; do
+  kubernetes_orgs+=("$(basename "$(dirname "${org_config}")")")
+done
+readonly kubernetes_orgs
 
 readonly gh_api_cmd=(
     gh api
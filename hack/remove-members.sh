@@ -14,26 +14,25 @@
 # limitations under the License.
 
 
-# This script removes members from all Kubernetes orgs by removing any
-# occurrence of their GitHub handle from files contained within the /config
-# directory. To preserve a clean git history, each removal is done as its own
-# commit, mirroring the process done when a member is added.
+# This script removes members from all Kubernetes orgs they belong to, along
+# with every team of those orgs, using `korg remove`. All removals are made in
+# a single commit listing every member removed.
 #
 # The script expects a file containing a list of GitHub handles, one per line.
 #
 # The environment variable `DRYRUN` controls whether the changes are simulated
-# or live. The default, `false` will print the user, files modified, lines
-# changed, and git commit command.
+# or live. The default, `true` will print the users and files that would be
+# modified.
 #
 # ENV:
-#   DRYRUN: {true,false} - default: false
+#   DRYRUN: {true,false} - default: true
 # ARGS:
 #   $1: path to a file containing a list of members to be removed
 # USAGE:
 #   DRYRUN={true,false} ./remove-members.sh <file>
 # EXAMPLES:
-#   DRYRUN=false ./remove-members.sh inactive-members.txt # Prints changes
-#   DRYRUN=true ./remove-members.sh inactive-members.txt  # Removes members
+#   DRYRUN=true ./remove-members.sh inactive-members.txt  # Prints changes
+#   DRYRUN=false ./remove-members.sh inactive-members.txt # Removes members
 
 
 set -o errexit
@@ -44,75 +43,44 @@ readonly REPO_ROOT="$(git rev-parse --show-toplevel)"
 readonly CONFIG_PATH="$REPO_ROOT/config"
 readonly DRYRUN="${DRYRUN:-true}"
 
-if [ ! -f "$1" ]; then
+if [ ! -f "${1:-}" ]; then
   echo "No file specified."
   exit 1
 fi
 
+cd "$REPO_ROOT"
+
 members=()
 mapfile -t members < "$1"
-for member in "${members[@]}"; do
-  matches=()
-  orgs=()
-  teams=()
-
-  # Assembles list of files containing member to be removed (\s+)?- ${member}(\s+|\s+?#.*)?$
-  mapfile -t matches < <(grep -rliP  --include="*.yaml" "(\s+)?- $member(\s+|\s+?#.*)?$" "$CONFIG_PATH")
 
-  for filename in "${matches[@]}"; do
-
-    if [ "$(basename "$filename")" == "org.yaml" ]; then
-      if [ "$DRYRUN" == "false" ]; then
-        sed -E -i "/(\s+)?- $member(\s+|\s+?#.*)?$/Id" "$filename"
-        git add "$filename"
-       else
-        grep -inHP "(\s+)?- $member(\s+|\s+?#.*)?$" "$filename"
-      fi
-      # Adds org component to array to build removal commit message
-      orgs+=("$(basename "$(dirname "$filename")")")
-    fi
-
-    if [ "$(basename "$filename")" == "teams.yaml" ]; then
-      if [ "$DRYRUN" == "false" ]; then
-        sed -E -i "/(\s+)?- $member(\s+|\s+?#.*)?$/Id" "$filename"
-        git add "$filename"
-       else
-         grep -inHP "(\s+)?- $member(\s+|\s+?#.*)?$" "$filename"
-      fi
-      # Adds team component to array to build removal commit message
-      # It is not perfect as teams can be in org files, but it does make 
-      # commit messages more descriptive when possible.
-      teams+=("$(basename "$(dirname "$filename")")")
+# Assembles the list of orgs any of the members belong to, members being
+# listed in the org.yaml of the org or in its members.d files, at any depth
+orgs=()
+for org_config in "$CONFIG_PATH"/*/org.yaml; do
+  org_dir="$(dirname "$org_config")"
+  member_files=("$org_config")
+  while IFS= read -r member_file; do
+    member_files+=("$member_file")
+  done < <(find "$org_dir" -path '*/members.d/*.yaml' -type f | sort)
+
+  for member in "${members[@]}"; do
+    [[ -z "$member" ]] && continue
+    if grep -qiP "^- \"?$member\"?(\s+|\s+?#.*)?$" "${member_files[@]}"; then
+      orgs+=("$(basename "$org_dir")")
+      break
     fi
   done
+done
 
-  sorted_unique_orgs=()
-  sorted_unique_teams=()
-
-  # Removes duplicates and sorts to build a better commit message.
-  mapfile -t sorted_unique_orgs < <(echo "${orgs[@]}" | tr ' ' '\n' | sort -u)
-  mapfile -t sorted_unique_teams < <(echo "${teams[@]}" | tr ' ' '\n' | sort -u)
-
-
-
-  org_commit_msg="Remove $member from the "
-  if [[ "${#sorted_unique_orgs[@]}" -eq "1" ]]; then
-    org_commit_msg+="${sorted_unique_orgs[*]} org"
-  elif [[ "${#sorted_unique_orgs[@]}" -ge "1" ]]; then
-    printf -v joined '%s, ' "${sorted_unique_orgs[@]}"
-    org_commit_msg+="${joined%, } orgs"
-  fi
+if [[ "${#orgs[@]}" -eq 0 ]]; then
+  echo "None of the members belong to any org."
+  exit 0
+fi
 
-  cmd="git commit -m \"$org_commit_msg\""
-  if [[ "${sorted_unique_teams[0]}" != "" ]]; then
-    for team in "${sorted_unique_teams[@]}"; do
-      cmd+=" -m \"Remove $member from $team teams\""
-    done
-  fi
+printf -v joined '%s,' "${orgs[@]}"
+cmd=(go run ./cmd/korg remove --from-file "$1" --org "${joined%,}")
+if [ "$DRYRUN" == "false" ]; then
+  cmd+=(--confirm)
+fi
 
-  if [ "$DRYRUN" == "false" ]; then
-    eval "$cmd"
-  else
-    echo "Command: $cmd"
-  fi
-done
+"${cmd[@]}"
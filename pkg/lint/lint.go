@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint checks the invariants org configs of this repo must hold,
+// reporting every violation along with the rule it breaks and where it is.
+package lint
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Severity is how much a finding matters. Only errors make a config invalid.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rule is an invariant of org configs.
+type Rule struct {
+	ID          string   `json:"id"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+
+	check func(o *Org, report reportFunc)
+}
+
+type reportFunc func(l Location, format string, args ...interface{})
+
+// Finding is a violation of a rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Org      string   `json:"org"`
+	Location
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", f.Location, f.Severity, f.Message, f.Rule)
+}
+
+// Check returns the violations of every rule by o, sorted by location.
+func Check(o *Org) []Finding {
+	findings := []Finding{}
+	for _, r := range Rules {
+		r.check(o, func(l Location, format string, args ...interface{}) {
+			findings = append(findings, Finding{
+				Rule:     r.ID,
+				Severity: r.Severity,
+				Org:      o.Name,
+				Location: l,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return findings
+}
+
+// Errors returns the findings of error severity.
+func Errors(findings []Finding) []Finding {
+	errs := []Finding{}
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			errs = append(errs, f)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+	"k8s.io/org/pkg/orgconfig"
+)
+
+const validOwners = `approvers:
+- alice
+- bob
+- carol
+- dave
+- erin
+reviewers:
+- frank
+`
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name: "valid org",
+			files: map[string]string{
+				"org.yaml": `admins:
+- alice
+- k8s-ci-robot
+members:
+- Bob
+- carol
+- dave
+- erin
+- frank
+teams:
+  parent:
+    maintainers:
+    - alice
+    members:
+    - bob
+    privacy: closed
+    teams:
+      child:
+        members:
+        - carol
+        privacy: closed
+`,
+				"OWNERS": validOwners,
+			},
+			expected: []string{},
+		},
+		{
+			name: "org lists",
+			files: map[string]string{
+				"org.yaml": `admins:
+- alice
+members:
+- carol
+- bob
+- alice
+- dave
+- Dave
+- erin
+- frank
+`,
+				"OWNERS": validOwners,
+			},
+			expected: []string{
+				"org.yaml:1: error [required-admin]",
+				"org.yaml:5: error [sorted]",
+				"org.yaml:6: error [admin-member-overlap]",
+				"org.yaml:8: error [duplicate]",
+			},
+		},
+		{
+			name: "members fragments",
+			files: map[string]string{
+				"org.yaml": `admins:
+- alice
+- k8s-ci-robot
+members:
+- bob
+- carol
+teams:
+  t:
+    members:
+    - dave
+    privacy: closed
+`,
+				"members.d/sig-bar.yaml": `admins:
+- gina
+`,
+				"members.d/sig-foo.yaml": `members:
+- dave
+- erin
+- frank
+- carol
+`,
+				"OWNERS": validOwners,
+			},
+			expected: []string{
+				"members.d/sig-foo.yaml:5: error [sorted]",
+				"members.d/sig-foo.yaml:5: error [duplicate]",
+			},
+		},
+		{
+			name: "teams",
+			files: map[string]string{
+				"org.yaml": `admins:
+- alice
+- k8s-ci-robot
+members:
+- bob
+- carol
+- dave
+- erin
+- frank
+teams:
+  open:
+    members:
+    - bob
+`,
+				"sig-foo/teams.yaml": `teams:
+  foo:
+    maintainers:
+    - alice
+    - bob
+    members:
+    - alice
+    - bob
+    - zed
+    privacy: closed
+    teams:
+      foo-child:
+        members:
+        - erin
+        - dave
+        privacy: secret
+`,
+				"sig-foo/nested/teams.yaml": "teams:\n  nested:\n    members:\n    - nobody\n",
+				"sig-bar/teams.yaml":        "teams:\n  empty:\n    privacy: closed\n  parent:\n    privacy: closed\n    teams:\n      child:\n        members:\n        - bob\n        privacy: closed\n",
+				"OWNERS":                    validOwners,
+			},
+			expected: []string{
+				"org.yaml:11: error [team-privacy]",
+				"sig-bar/teams.yaml:2: warning [team-empty]",
+				"sig-foo/nested/teams.yaml:2: error [team-privacy]",
+				"sig-foo/nested/teams.yaml:4: error [team-member-not-in-org]",
+				"sig-foo/teams.yaml:5: error [team-maintainer-not-admin]",
+				"sig-foo/teams.yaml:7: error [team-role-overlap]",
+				"sig-foo/teams.yaml:7: error [team-admin-member]",
+				"sig-foo/teams.yaml:8: error [team-role-overlap]",
+				"sig-foo/teams.yaml:9: error [team-member-not-in-org]",
+				"sig-foo/teams.yaml:12: error [team-privacy]",
+				"sig-foo/teams.yaml:15: error [sorted]",
+			},
+		},
+		{
+			name: "owners",
+			files: map[string]string{
+				"org.yaml": "admins:\n- alice\n- k8s-ci-robot\nmembers:\n- bob\n",
+				"OWNERS":   "# comment\napprovers:\n- alice\n- bob\n- zed\n- Alice\n",
+			},
+			expected: []string{
+				"OWNERS:2: error [owners-min-approvers]",
+				"OWNERS:5: error [owners-not-member]",
+				"OWNERS:6: error [duplicate]",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := testutil.WriteFiles(t, tc.files)
+			o, err := LoadOrg(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if o.Name != filepath.Base(dir) {
+				t.Errorf("unexpected org name %q", o.Name)
+			}
+
+			got := []string{}
+			for _, f := range Check(o) {
+				got = append(got, strings.TrimPrefix(f.Location.String(), dir+"/")+": "+string(f.Severity)+" ["+f.Rule+"]")
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected findings:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(tc.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestLoadOrgErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name:  "unknown field",
+			files: map[string]string{"org.yaml": "admin:\n- alice\n", "OWNERS": validOwners},
+		},
+		{
+			name:  "invalid teams file",
+			files: map[string]string{"org.yaml": "admins:\n- alice\n", "sig-foo/teams.yaml": "teams: [", "OWNERS": validOwners},
+		},
+		{
+			name: "team defined twice",
+			files: map[string]string{
+				"org.yaml":                  "admins:\n- alice\nteams:\n  foo:\n    privacy: closed\n",
+				"sig-foo/nested/teams.yaml": "teams:\n  foo:\n    privacy: closed\n",
+				"OWNERS":                    validOwners,
+			},
+		},
+		{
+			name:  "missing OWNERS",
+			files: map[string]string{"org.yaml": "admins:\n- alice\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := LoadOrg(testutil.WriteFiles(t, tc.files)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadOrgOverrides(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"test-org/org.yaml":           "admins:\n- alice\n- k8s-ci-robot\nmembers:\n- bob\n- carol\n- dave\n- erin\n- frank\nteams:\n  dup:\n    members:\n    - zed\n    privacy: closed\n",
+		"test-org/sig-foo/teams.yaml": "teams:\n  dup:\n    members:\n    - bob\n    privacy: closed\n",
+		"test-org/OWNERS":             validOwners,
+		orgconfig.OverridesFile:       "orgs:\n  test-org:\n    teams:\n      dup: sig-foo/teams.yaml\n",
+	})
+
+	o, err := LoadOrg(filepath.Join(root, "test-org"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, f := range Check(o) {
+		if f.Severity == SeverityError {
+			t.Errorf("unexpected finding: %v", f)
+		}
+	}
+}
+
+func TestSortedMessage(t *testing.T) {
+	dir := testutil.WriteFiles(t, map[string]string{
+		"org.yaml": "admins:\n- k8s-ci-robot\nmembers:\n- alice\n- dave\n- carol\n- bob\n- erin\n",
+		"OWNERS":   "approvers:\n- alice\n- bob\n- carol\n- dave\n- erin\n",
+	})
+	o, err := LoadOrg(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	findings := Check(o)
+	if len(findings) != 1 {
+		t.Fatalf("expected a single finding, got %v", findings)
+	}
+	expected := "carol is out of order in the members of org " + filepath.Base(dir) + ": carol (line 6) must come before dave (line 5)"
+	if findings[0].Message != expected || findings[0].Line != 6 {
+		t.Errorf("unexpected finding %v, expected %q on line 6", findings[0], expected)
+	}
+}
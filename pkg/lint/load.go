@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/prow/pkg/config/org"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// Location is where something is defined in a config file.
+type Location = orgconfig.Source
+
+// Owners is the part of an OWNERS file the rules check.
+type Owners struct {
+	Approvers []string `json:"approvers"`
+	Reviewers []string `json:"reviewers,omitempty"`
+}
+
+// Org is the config of an org as read from its directory: its org.yaml, the
+// teams.yaml and fragments of its subdirectories and its OWNERS file, along
+// with where each part of it is defined.
+type Org struct {
+	// Name is the name of the directory of the org.
+	Name   string
+	Config org.Config
+	Owners Owners
+
+	orgFile    string
+	ownersFile string
+	// keys locates mapping keys by path, e.g. teams/foo/members, as first
+	// found
+	keys map[string]Location
+	// lists holds the entries of lists by path, in order. Lists found at the
+	// same path in several files, e.g. members, follow each other in the
+	// order of the files.
+	lists map[string][]listEntry
+	// loaded is the config as merged by orgconfig, telling which definition
+	// of overridden teams is kept
+	loaded *orgconfig.Org
+}
+
+// listEntry is an entry of a list, along with where it is.
+type listEntry struct {
+	Location
+	Value string
+}
+
+// LoadOrg reads the org config in dir, see orgconfig.LoadOrg, along with its
+// OWNERS file.
+func LoadOrg(dir string) (*Org, error) {
+	o := &Org{
+		Name:       filepath.Base(dir),
+		orgFile:    filepath.Join(dir, orgconfig.OrgFile),
+		ownersFile: filepath.Join(dir, "OWNERS"),
+		keys:       map[string]Location{},
+		lists:      map[string][]listEntry{},
+	}
+
+	loaded, err := orgconfig.LoadOrg(o.orgFile)
+	if err != nil {
+		return nil, err
+	}
+	o.Config = loaded.Config
+	o.loaded = loaded
+	for _, path := range loaded.Files {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+		}
+		if err := o.locate(path, contents); err != nil {
+			return nil, err
+		}
+	}
+
+	contents, err := os.ReadFile(o.ownersFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file at %s: %s", o.ownersFile, err)
+	}
+	if err := sigsyaml.Unmarshal(contents, &o.Owners); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal %s: %s", o.ownersFile, err)
+	}
+	if err := o.locate(o.ownersFile, contents); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// locate records where the keys and list entries of the file at path are.
+// Locations of OWNERS files are recorded under the owners/ prefix.
+func (o *Org) locate(path string, contents []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return fmt.Errorf("unable to parse %s: %s", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	prefix := ""
+	if path == o.ownersFile {
+		prefix = "owners"
+	}
+	o.walk(path, prefix, doc.Content[0])
+	return nil
+}
+
+func (o *Org) walk(file, path string, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if path == "teams" && o.dropped(file, node.Content[i].Value) {
+				continue
+			}
+			key := joinPath(path, node.Content[i].Value)
+			if _, found := o.keys[key]; !found {
+				o.keys[key] = Location{File: file, Line: node.Content[i].Line}
+			}
+			o.walk(file, key, node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			o.lists[path] = append(o.lists[path], listEntry{Location{File: file, Line: item.Line}, item.Value})
+		}
+	}
+}
+
+// dropped returns whether the definition of the top-level team name in file
+// was dropped in favor of the one of another file, as the team is overridden.
+func (o *Org) dropped(file, name string) bool {
+	if o.loaded == nil {
+		return false
+	}
+	source, found := o.loaded.TeamSource(name)
+	return found && source.File != file
+}
+
+func joinPath(parts ...string) string {
+	nonEmpty := []string{}
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, "/")
+}
+
+// key returns where the key at path is defined, or the file it would be in.
+func (o *Org) key(path string) Location {
+	if l, found := o.keys[path]; found {
+		return l
+	}
+	if strings.HasPrefix(path, "owners/") {
+		return Location{File: o.ownersFile}
+	}
+	return Location{File: o.orgFile}
+}
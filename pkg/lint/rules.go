@@ -0,0 +1,318 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+
+	"k8s.io/org/pkg/order"
+)
+
+const (
+	// RequiredAdmin must be an admin of every org.
+	RequiredAdmin = "k8s-ci-robot"
+	// MinApprovers is the minimum number of approvers in the OWNERS file of
+	// an org.
+	MinApprovers = 5
+)
+
+// Rules lists every rule, checked in order.
+var Rules = []Rule{
+	{
+		ID:          "sorted",
+		Severity:    SeverityError,
+		Description: "org admins and members, and team maintainers and members, are sorted as GitHub normalizes logins, i.e. case-insensitively",
+		check:       checkSorted,
+	},
+	{
+		ID:          "duplicate",
+		Severity:    SeverityError,
+		Description: "users are listed at most once in each list",
+		check:       checkDuplicates,
+	},
+	{
+		ID:          "admin-member-overlap",
+		Severity:    SeverityError,
+		Description: "org admins are not listed as org members",
+		check:       checkAdminMemberOverlap,
+	},
+	{
+		ID:          "required-admin",
+		Severity:    SeverityError,
+		Description: RequiredAdmin + " is an org admin",
+		check:       checkRequiredAdmin,
+	},
+	{
+		ID:          "team-privacy",
+		Severity:    SeverityError,
+		Description: "teams have privacy: closed",
+		check:       checkTeamPrivacy,
+	},
+	{
+		ID:          "team-empty",
+		Severity:    SeverityWarning,
+		Description: "teams have maintainers, members or child teams",
+		check:       checkTeamEmpty,
+	},
+	{
+		ID:          "team-maintainer-not-admin",
+		Severity:    SeverityError,
+		Description: "team maintainers are org admins",
+		check:       checkTeamMaintainers,
+	},
+	{
+		ID:          "team-role-overlap",
+		Severity:    SeverityError,
+		Description: "team members are not also team maintainers",
+		check:       checkTeamRoleOverlap,
+	},
+	{
+		ID:          "team-member-not-in-org",
+		Severity:    SeverityError,
+		Description: "team members are org members or admins",
+		check:       checkTeamMembersInOrg,
+	},
+	{
+		ID:          "team-admin-member",
+		Severity:    SeverityError,
+		Description: "org admins are team maintainers rather than team members",
+		check:       checkTeamAdminMembers,
+	},
+	{
+		ID:          "owners-min-approvers",
+		Severity:    SeverityError,
+		Description: "the OWNERS file of the org has enough approvers",
+		check:       checkMinApprovers,
+	},
+	{
+		ID:          "owners-not-member",
+		Severity:    SeverityError,
+		Description: "OWNERS approvers and reviewers are org members or admins",
+		check:       checkOwnersInOrg,
+	},
+}
+
+func normalize(list []string) sets.String {
+	out := sets.String{}
+	for _, l := range list {
+		out.Insert(github.NormLogin(l))
+	}
+	return out
+}
+
+func (o *Org) admins() sets.String {
+	return normalize(o.Config.Admins)
+}
+
+func (o *Org) allMembers() sets.String {
+	return normalize(o.Config.Members).Union(o.admins())
+}
+
+// eachTeam calls f with every team of the org, children included, in order
+// of name. Children are named <parent>/<child>.
+func (o *Org) eachTeam(f func(name, path string, team org.Team)) {
+	var walk func(prefix, path string, teams map[string]org.Team)
+	walk = func(prefix, path string, teams map[string]org.Team) {
+		names := make([]string, 0, len(teams))
+		for name := range teams {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			team := teams[name]
+			teamName := joinPath(prefix, name)
+			teamPath := joinPath(path, "teams", name)
+			f(teamName, teamPath, team)
+			walk(teamName, teamPath, team.Children)
+		}
+	}
+	walk("", "", o.Config.Teams)
+}
+
+// userList is a list of users of the config, along with its path and what it
+// is, for messages.
+type userList struct {
+	path    string
+	what    string
+	entries []listEntry
+}
+
+func (o *Org) userLists(includeOwners bool) []userList {
+	lists := []userList{
+		{"admins", "the admins of org " + o.Name, o.lists["admins"]},
+		{"members", "the members of org " + o.Name, o.lists["members"]},
+	}
+	o.eachTeam(func(name, path string, team org.Team) {
+		maintainers, members := joinPath(path, "maintainers"), joinPath(path, "members")
+		lists = append(lists,
+			userList{maintainers, "the maintainers of team " + name, o.lists[maintainers]},
+			userList{members, "the members of team " + name, o.lists[members]},
+		)
+	})
+	if includeOwners {
+		lists = append(lists,
+			userList{"owners/approvers", "the OWNERS approvers", o.lists["owners/approvers"]},
+			userList{"owners/reviewers", "the OWNERS reviewers", o.lists["owners/reviewers"]},
+		)
+	}
+	return lists
+}
+
+// byFile splits entries by file, keeping their order.
+func byFile(entries []listEntry) [][]listEntry {
+	var files [][]listEntry
+	for i, e := range entries {
+		if i == 0 || e.File != entries[i-1].File {
+			files = append(files, nil)
+		}
+		files[len(files)-1] = append(files[len(files)-1], e)
+	}
+	return files
+}
+
+func checkSorted(o *Org, report reportFunc) {
+	for _, l := range o.userLists(false) {
+		for _, entries := range byFile(l.entries) {
+			logins, lines := []string{}, []int{}
+			for _, e := range entries {
+				logins = append(logins, e.Value)
+				lines = append(lines, e.Line)
+			}
+			if v := order.FirstUnsorted(order.Entries(logins, lines)); v != nil {
+				report(entries[v.Index].Location, "%s is out of order in %s: %s", v.Next.Login, l.what, v)
+			}
+		}
+	}
+}
+
+func checkDuplicates(o *Org, report reportFunc) {
+	for _, l := range o.userLists(true) {
+		seen := sets.String{}
+		for _, e := range l.entries {
+			id := github.NormLogin(e.Value)
+			if seen.Has(id) {
+				report(e.Location, "%s is listed more than once in %s", e.Value, l.what)
+			}
+			seen.Insert(id)
+		}
+	}
+}
+
+func checkAdminMemberOverlap(o *Org, report reportFunc) {
+	admins := o.admins()
+	for _, e := range o.lists["members"] {
+		if admins.Has(github.NormLogin(e.Value)) {
+			report(e.Location, "%s is both an admin and a member of org %s, admins must not be listed as members", e.Value, o.Name)
+		}
+	}
+}
+
+func checkRequiredAdmin(o *Org, report reportFunc) {
+	if !o.admins().Has(RequiredAdmin) {
+		report(o.key("admins"), "%s must be an admin of org %s", RequiredAdmin, o.Name)
+	}
+}
+
+func checkTeamPrivacy(o *Org, report reportFunc) {
+	o.eachTeam(func(name, path string, team org.Team) {
+		if team.Privacy == nil || *team.Privacy != org.Closed {
+			report(o.key(path), "team %s doesn't have the `privacy: closed` field", name)
+		}
+	})
+}
+
+func checkTeamEmpty(o *Org, report reportFunc) {
+	o.eachTeam(func(name, path string, team org.Team) {
+		if len(team.Maintainers) == 0 && len(team.Members) == 0 && len(team.Children) == 0 {
+			report(o.key(path), "team %s has no maintainers, members or child teams", name)
+		}
+	})
+}
+
+func checkTeamMaintainers(o *Org, report reportFunc) {
+	admins := o.admins()
+	o.eachTeam(func(name, path string, team org.Team) {
+		for _, e := range o.lists[joinPath(path, "maintainers")] {
+			if !admins.Has(github.NormLogin(e.Value)) {
+				report(e.Location, "%s is a maintainer of team %s but not an admin of org %s, they must be in the members list instead", e.Value, name, o.Name)
+			}
+		}
+	})
+}
+
+func checkTeamRoleOverlap(o *Org, report reportFunc) {
+	o.eachTeam(func(name, path string, team org.Team) {
+		maintainers := normalize(team.Maintainers)
+		for _, e := range o.lists[joinPath(path, "members")] {
+			if maintainers.Has(github.NormLogin(e.Value)) {
+				report(e.Location, "%s is both a maintainer and a member of team %s", e.Value, name)
+			}
+		}
+	})
+}
+
+func checkTeamMembersInOrg(o *Org, report reportFunc) {
+	orgMembers := o.allMembers()
+	o.eachTeam(func(name, path string, team org.Team) {
+		for _, e := range o.lists[joinPath(path, "members")] {
+			if !orgMembers.Has(github.NormLogin(e.Value)) {
+				report(e.Location, "%s is a member of team %s but not of org %s", e.Value, name, o.Name)
+			}
+		}
+	})
+}
+
+func checkTeamAdminMembers(o *Org, report reportFunc) {
+	admins := o.admins()
+	o.eachTeam(func(name, path string, team org.Team) {
+		for _, e := range o.lists[joinPath(path, "members")] {
+			if admins.Has(github.NormLogin(e.Value)) {
+				report(e.Location, "%s is an admin of org %s, they must be in the maintainers list of team %s instead of its members", e.Value, o.Name, name)
+			}
+		}
+	})
+}
+
+func checkMinApprovers(o *Org, report reportFunc) {
+	approvers := normalize(o.Owners.Approvers)
+	if n := len(approvers); n < MinApprovers {
+		report(o.key("owners/approvers"), "OWNERS must have at least %d approvers, found %d: %s", MinApprovers, n, strings.Join(approvers.List(), ", "))
+	}
+}
+
+func checkOwnersInOrg(o *Org, report reportFunc) {
+	orgMembers := o.allMembers()
+	for _, l := range []struct {
+		path string
+		role string
+	}{
+		{"owners/approvers", "approver"},
+		{"owners/reviewers", "reviewer"},
+	} {
+		for _, e := range o.lists[l.path] {
+			if !orgMembers.Has(github.NormLogin(e.Value)) {
+				report(e.Location, "%s is an OWNERS %s but not a member of org %s", e.Value, l.role, o.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package order defines the order lists of GitHub logins are kept in across
+// config files: logins are compared as GitHub normalizes them, i.e. ignoring
+// case and a leading @.
+package order
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// Less reports whether login a sorts before login b.
+func Less(a, b string) bool {
+	return github.NormLogin(a) < github.NormLogin(b)
+}
+
+// Equal reports whether logins a and b are the same login.
+func Equal(a, b string) bool {
+	return github.NormLogin(a) == github.NormLogin(b)
+}
+
+// Sort sorts logins, keeping the relative order of equal logins.
+func Sort(logins []string) {
+	sort.SliceStable(logins, func(i, j int) bool {
+		return Less(logins[i], logins[j])
+	})
+}
+
+// IsSorted reports whether logins are sorted. Equal logins next to each other
+// are sorted.
+func IsSorted(logins []string) bool {
+	return FirstUnsorted(Entries(logins, nil)) == nil
+}
+
+// Entry is a login of a list, along with the line it is on, 0 if unknown.
+type Entry struct {
+	Login string
+	Line  int
+}
+
+func (e Entry) String() string {
+	if e.Line == 0 {
+		return e.Login
+	}
+	return fmt.Sprintf("%s (line %d)", e.Login, e.Line)
+}
+
+// Entries pairs logins with the lines they are on, lines may be shorter than
+// logins or nil if unknown.
+func Entries(logins []string, lines []int) []Entry {
+	entries := make([]Entry, 0, len(logins))
+	for i, login := range logins {
+		e := Entry{Login: login}
+		if i < len(lines) {
+			e.Line = lines[i]
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Violation is a pair of consecutive entries out of order.
+type Violation struct {
+	// Index of Next in the list.
+	Index    int
+	Previous Entry
+	Next     Entry
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s must come before %s", v.Next, v.Previous)
+}
+
+// FirstUnsorted returns the first pair of entries out of order, nil if the
+// entries are sorted. Moving Next before Previous is the first step to
+// sorting the list.
+func FirstUnsorted(entries []Entry) *Violation {
+	for i := 1; i < len(entries); i++ {
+		if Less(entries[i].Login, entries[i-1].Login) {
+			return &Violation{Index: i, Previous: entries[i-1], Next: entries[i]}
+		}
+	}
+	return nil
+}
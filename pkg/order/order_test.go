@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package order
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFirstUnsorted(t *testing.T) {
+	cases := []struct {
+		desc     string
+		logins   []string
+		lines    []int
+		expected *Violation
+	}{
+		{
+			desc:   "empty",
+			logins: []string{},
+		},
+		{
+			desc:   "sorted ignoring case",
+			logins: []string{"alice", "Bob", "carol", "DAVE"},
+		},
+		{
+			desc:   "sorted ignoring a leading @",
+			logins: []string{"alice", "@bob", "carol"},
+		},
+		{
+			desc:   "equal logins",
+			logins: []string{"alice", "Bob", "bob", "carol"},
+		},
+		{
+			desc:     "uppercase sorted before lowercase",
+			logins:   []string{"Zed", "alice"},
+			lines:    []int{3, 4},
+			expected: &Violation{Index: 1, Previous: Entry{"Zed", 3}, Next: Entry{"alice", 4}},
+		},
+		{
+			desc:     "first violation only",
+			logins:   []string{"alice", "dave", "carol", "bob"},
+			lines:    []int{10, 11, 12, 13},
+			expected: &Violation{Index: 2, Previous: Entry{"dave", 11}, Next: Entry{"carol", 12}},
+		},
+		{
+			desc:     "unknown lines",
+			logins:   []string{"bob", "@alice"},
+			expected: &Violation{Index: 1, Previous: Entry{Login: "bob"}, Next: Entry{Login: "@alice"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := FirstUnsorted(Entries(tc.logins, tc.lines))
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected violation: %v, expected %v", got, tc.expected)
+			}
+			if IsSorted(tc.logins) != (tc.expected == nil) {
+				t.Errorf("IsSorted disagrees with FirstUnsorted")
+			}
+		})
+	}
+}
+
+func TestViolationString(t *testing.T) {
+	v := FirstUnsorted(Entries([]string{"carol", "bob"}, []int{7, 8}))
+	if got, expected := v.String(), "bob (line 8) must come before carol (line 7)"; got != expected {
+		t.Errorf("unexpected message %q, expected %q", got, expected)
+	}
+}
+
+func TestSort(t *testing.T) {
+	logins := []string{"carol", "@Bob", "alice", "bob", "Alice"}
+	Sort(logins)
+	expected := []string{"alice", "Alice", "@Bob", "bob", "carol"}
+	if !reflect.DeepEqual(logins, expected) {
+		t.Errorf("unexpected order: %v, expected %v", logins, expected)
+	}
+}
@@ -0,0 +1,773 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package orgconfig loads the org configs of this repo: for each org, an
+// org.yaml and the teams.yaml, repos.yaml and members.d/*.yaml files below its
+// directory, merged into a single org config, keeping track of the file and
+// line defining each team, member, repo and repo permission.
+package orgconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// OrgFile is the name of the file configuring an org.
+	OrgFile = "org.yaml"
+	// TeamsFile is the name of the files configuring teams of an org.
+	TeamsFile = "teams.yaml"
+	// ReposFile is the name of the files configuring repos of an org.
+	ReposFile = "repos.yaml"
+	// MembersDir is the name of the directories holding files listing admins
+	// and members of an org, with a .yaml extension.
+	MembersDir = "members.d"
+	// OverridesFile is the name of the team overrides file, next to the
+	// directories of the orgs it applies to.
+	OverridesFile = "team-overrides.yaml"
+)
+
+// Unmarshal decodes an org config, rejecting unknown fields.
+func Unmarshal(buf []byte) (*org.Config, error) {
+	var cfg org.Config
+	if err := sigsyaml.Unmarshal(buf, &cfg, sigsyaml.DisallowUnknownFields); err != nil {
+		return nil, fmt.Errorf("unmarshal: %v", err)
+	}
+	return &cfg, nil
+}
+
+// ReadConfig reads the org config at path, rejecting unknown fields.
+func ReadConfig(path string) (*org.Config, error) {
+	cfg, _, err := readFile(path)
+	return cfg, err
+}
+
+// readFile reads the org config at path along with its YAML node tree, nil
+// for an empty file.
+func readFile(path string) (*org.Config, *yaml.Node, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read: %v", err)
+	}
+	cfg, err := Unmarshal(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parse: %v", err)
+	}
+	if len(doc.Content) == 0 {
+		return cfg, nil, nil
+	}
+	return cfg, doc.Content[0], nil
+}
+
+// TeamsFiles returns every teams.yaml below the directory of the org.yaml at
+// orgPath, at any depth, in lexical order.
+func TeamsFiles(orgPath string) ([]string, error) {
+	prefix := filepath.Dir(orgPath)
+	var paths []string
+	err := filepath.Walk(prefix, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case !info.IsDir() && info.Name() == TeamsFile:
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %v", prefix, err)
+	}
+	return paths, nil
+}
+
+// FragmentFiles returns the repos.yaml files, and the .yaml files of the
+// members.d directories, below the directory of the org.yaml at orgPath, at
+// any depth, in lexical order.
+func FragmentFiles(orgPath string) ([]string, error) {
+	prefix := filepath.Dir(orgPath)
+	var paths []string
+	err := filepath.Walk(prefix, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case info.IsDir():
+		case info.Name() == ReposFile:
+			paths = append(paths, path)
+		case filepath.Base(filepath.Dir(path)) == MembersDir && filepath.Ext(path) == ".yaml":
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %v", prefix, err)
+	}
+	return paths, nil
+}
+
+// Source is where part of an org config is defined.
+type Source struct {
+	File string `json:"file"`
+	// Line is 0 if unknown.
+	Line int `json:"line,omitempty"`
+}
+
+func (s Source) String() string {
+	if s.Line == 0 {
+		return s.File
+	}
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// Org is the config of an org, with the teams of every teams.yaml of the org
+// merged in.
+type Org struct {
+	// Name is the name of the directory of the org.
+	Name   string
+	Config org.Config
+	// Files lists the files the config was read from, org.yaml first.
+	Files []string
+
+	// teams maps team names, as <parent>/<child> for child teams, to where
+	// they are defined
+	teams map[string]Source
+	// teamNames maps the lowercase names of every team, children included,
+	// to where they are defined, as GitHub requires them to be unique
+	teamNames map[string]Source
+	// members maps normalized logins of org admins and members to where they
+	// are listed
+	members map[string]Source
+	// teamMembers maps team names and normalized logins of their
+	// maintainers and members, as <team>:<login>, to where they are listed
+	teamMembers map[string]Source
+	// teamRepos maps team names and the repos they have permissions on, as
+	// <team>:<repo>, to where the permissions are set
+	teamRepos map[string]Source
+	// repos maps repo names to where they are first configured
+	repos map[string]Source
+}
+
+// Overrides maps the top-level teams of an org allowed to be defined by more
+// than one file to the file whose definition is kept, relative to the
+// directory of the org, e.g. sig-foo/teams.yaml.
+type Overrides map[string]string
+
+// OverridesConfig is the format of a team overrides file.
+type OverridesConfig struct {
+	Orgs map[string]OrgOverrides `json:"orgs,omitempty"`
+}
+
+// OrgOverrides are the team overrides of an org.
+type OrgOverrides struct {
+	Teams Overrides `json:"teams,omitempty"`
+}
+
+// ReadOverrides reads the team overrides file at path, rejecting unknown
+// fields. See OverridesFile for where it is looked up.
+func ReadOverrides(path string) (*OverridesConfig, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %v", err)
+	}
+	var cfg OverridesConfig
+	if err := sigsyaml.Unmarshal(buf, &cfg, sigsyaml.DisallowUnknownFields); err != nil {
+		return nil, fmt.Errorf("unmarshal: %v", err)
+	}
+	return &cfg, nil
+}
+
+// LoadOrg reads the org.yaml at orgPath and merges in the teams of every
+// teams.yaml below its directory, see TeamsFiles, then the fragments below it,
+// see FragmentFiles. Each team, children included, must be defined once across
+// those files, as GitHub requires the names of the teams of an org to be
+// unique. Fragments are merged as follows:
+//   - the admins and members of members.d files are added to those of the
+//     org, logins listed more than once being kept once. Logins listed as
+//     admins in one file and as members in another are an error.
+//   - the repos of repos.yaml files are added to those of the org. Repos
+//     configured by more than one file get the fields set by each of them;
+//     fields set to different values are an error, except previously, whose
+//     lists are merged.
+//
+// The top-level teams listed for the org in the OverridesFile next to its
+// directory, if any, may be defined by more than one file, see
+// LoadOrgWithOverrides.
+func LoadOrg(orgPath string) (*Org, error) {
+	overrides, err := readOrgOverrides(orgPath)
+	if err != nil {
+		return nil, err
+	}
+	return LoadOrgWithOverrides(orgPath, overrides)
+}
+
+// readOrgOverrides returns the team overrides of the org configured at
+// orgPath, read from the OverridesFile next to the directory of the org.
+func readOrgOverrides(orgPath string) (Overrides, error) {
+	dir := filepath.Dir(orgPath)
+	path := filepath.Join(filepath.Dir(dir), OverridesFile)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	cfg, err := ReadOverrides(path)
+	if err != nil {
+		return nil, fmt.Errorf("error in %s: %v", path, err)
+	}
+	return cfg.Orgs[filepath.Base(dir)].Teams, nil
+}
+
+// teamsFile is the teams of a file, along with their node tree.
+type teamsFile struct {
+	path  string
+	teams map[string]org.Team
+	node  *yaml.Node
+}
+
+// LoadOrgWithOverrides is LoadOrg, except that the top-level teams listed in
+// overrides may be defined by more than one file. The definition in the file
+// they are mapped to is kept, the others are dropped. Overrides of teams
+// defined once, or not by the file they are mapped to, are an error.
+func LoadOrgWithOverrides(orgPath string, overrides Overrides) (*Org, error) {
+	return loadOrg(orgPath, overrides, true)
+}
+
+// LoadOrgWithoutTeamsFiles is LoadOrg, except that teams.yaml files are
+// ignored: the teams of the org are the ones of its org.yaml. Fragments are
+// still merged in.
+func LoadOrgWithoutTeamsFiles(orgPath string) (*Org, error) {
+	return loadOrg(orgPath, nil, false)
+}
+
+func loadOrg(orgPath string, overrides Overrides, mergeTeams bool) (*Org, error) {
+	o := &Org{
+		Name:        filepath.Base(filepath.Dir(orgPath)),
+		teams:       map[string]Source{},
+		teamNames:   map[string]Source{},
+		members:     map[string]Source{},
+		teamMembers: map[string]Source{},
+		teamRepos:   map[string]Source{},
+		repos:       map[string]Source{},
+	}
+
+	cfg, root, err := readFile(orgPath)
+	if err != nil {
+		return nil, fmt.Errorf("error in %s: %v", orgPath, err)
+	}
+	_, teamsNode := mappingEntry(root, "teams")
+	files := []teamsFile{{path: orgPath, teams: cfg.Teams, node: teamsNode}}
+	cfg.Teams = map[string]org.Team{}
+	o.Config = *cfg
+
+	for _, field := range []string{"admins", "members"} {
+		_, list := mappingEntry(root, field)
+		o.recordLogins(o.members, "", orgPath, list)
+	}
+	_, repos := mappingEntry(root, "repos")
+	o.recordRepos(orgPath, repos)
+
+	var paths []string
+	if mergeTeams {
+		if paths, err = TeamsFiles(orgPath); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range paths {
+		teamCfg, teamRoot, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error in %s: %v", path, err)
+		}
+		_, teamsNode := mappingEntry(teamRoot, "teams")
+		files = append(files, teamsFile{path: path, teams: teamCfg.Teams, node: teamsNode})
+	}
+
+	kept, err := resolveOverrides(orgPath, files, overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	var dups []string
+	for _, f := range files {
+		o.Files = append(o.Files, f.path)
+		for _, name := range sortedKeys(f.teams) {
+			if path, overridden := kept[name]; overridden && path != f.path {
+				continue
+			}
+			key, node := mappingEntry(f.node, name)
+			o.Config.Teams[name] = f.teams[name]
+			dups = append(dups, o.recordTeam(name, name, Source{File: f.path, Line: line(key)}, node)...)
+		}
+	}
+	if len(dups) > 0 {
+		return nil, errors.New(strings.Join(dups, "\n"))
+	}
+
+	fragments, err := FragmentFiles(orgPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range fragments {
+		if err := o.addFragment(path); err != nil {
+			return nil, fmt.Errorf("error in %s: %v", path, err)
+		}
+	}
+
+	return o, nil
+}
+
+// membersFragment is the format of members.d files.
+type membersFragment struct {
+	Admins  []string `json:"admins,omitempty"`
+	Members []string `json:"members,omitempty"`
+}
+
+// reposFragment is the format of repos.yaml files.
+type reposFragment struct {
+	Repos map[string]org.Repo `json:"repos,omitempty"`
+}
+
+// addFragment merges the fragment at path into the org, see LoadOrg.
+func (o *Org) addFragment(path string) error {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read: %v", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return fmt.Errorf("parse: %v", err)
+	}
+	var root *yaml.Node
+	if len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	o.Files = append(o.Files, path)
+
+	if filepath.Base(path) != ReposFile {
+		var fragment membersFragment
+		if err := sigsyaml.Unmarshal(buf, &fragment, sigsyaml.DisallowUnknownFields); err != nil {
+			return fmt.Errorf("unmarshal: %v", err)
+		}
+		var conflicts []string
+		for _, field := range []string{"admins", "members"} {
+			_, list := mappingEntry(root, field)
+			conflicts = append(conflicts, o.roleConflicts(path, field, list)...)
+			if field == "admins" {
+				o.Config.Admins = union(o.Config.Admins, fragment.Admins)
+			} else {
+				o.Config.Members = union(o.Config.Members, fragment.Members)
+			}
+			o.recordLogins(o.members, "", path, list)
+		}
+		if len(conflicts) > 0 {
+			return errors.New(strings.Join(conflicts, "\n"))
+		}
+		return nil
+	}
+
+	var fragment reposFragment
+	if err := sigsyaml.Unmarshal(buf, &fragment, sigsyaml.DisallowUnknownFields); err != nil {
+		return fmt.Errorf("unmarshal: %v", err)
+	}
+	_, repos := mappingEntry(root, "repos")
+	if o.Config.Repos == nil && len(fragment.Repos) > 0 {
+		o.Config.Repos = map[string]org.Repo{}
+	}
+
+	var conflicts []string
+	for _, name := range sortedKeys(fragment.Repos) {
+		existing, found := o.Config.Repos[name]
+		if !found {
+			o.Config.Repos[name] = fragment.Repos[name]
+			continue
+		}
+
+		key, _ := mappingEntry(repos, name)
+		source := Source{File: path, Line: line(key)}
+		merged, fields, err := mergeRepo(existing, fragment.Repos[name])
+		if err != nil {
+			return fmt.Errorf("repo %s: %v", name, err)
+		}
+		for _, field := range fields {
+			conflicts = append(conflicts, fmt.Sprintf("%s of repo %s is set to different values in %s and %s", field, name, o.repos[name], source))
+		}
+		o.Config.Repos[name] = merged
+	}
+	o.recordRepos(path, repos)
+	if len(conflicts) > 0 {
+		return errors.New(strings.Join(conflicts, "\n"))
+	}
+	return nil
+}
+
+// roleConflicts describes the logins of list, the admins or members listed by
+// the fragment at path, that the org already has in the other role.
+func (o *Org) roleConflicts(path, field string, list *yaml.Node) []string {
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return nil
+	}
+	other, otherField := o.Config.Members, "members"
+	if field == "members" {
+		other, otherField = o.Config.Admins, "admins"
+	}
+	listed := map[string]bool{}
+	for _, login := range other {
+		listed[github.NormLogin(login)] = true
+	}
+
+	var conflicts []string
+	for _, item := range list.Content {
+		if login := github.NormLogin(item.Value); listed[login] {
+			source := Source{File: path, Line: item.Line}
+			conflicts = append(conflicts, fmt.Sprintf("%s is one of the %s in %s and of the %s in %s", item.Value, otherField, o.members[login], field, source))
+		}
+	}
+	return conflicts
+}
+
+// union returns logins followed by the logins of more not already in it.
+func union(logins, more []string) []string {
+	seen := map[string]bool{}
+	for _, login := range logins {
+		seen[github.NormLogin(login)] = true
+	}
+	for _, login := range more {
+		if !seen[github.NormLogin(login)] {
+			seen[github.NormLogin(login)] = true
+			logins = append(logins, login)
+		}
+	}
+	return logins
+}
+
+// mergeRepo returns repo with the fields set in more, along with the fields,
+// by JSON name, set to different values in both. The lists of previous names
+// of the repo are merged.
+func mergeRepo(repo, more org.Repo) (org.Repo, []string, error) {
+	fields, err := repoFields(repo)
+	if err != nil {
+		return repo, nil, err
+	}
+	moreFields, err := repoFields(more)
+	if err != nil {
+		return repo, nil, err
+	}
+
+	var conflicts []string
+	for _, name := range sortedKeys(moreFields) {
+		value, found := fields[name]
+		switch {
+		case !found:
+			fields[name] = moreFields[name]
+		case name == "previously":
+		case !reflect.DeepEqual(value, moreFields[name]):
+			conflicts = append(conflicts, name)
+		}
+	}
+
+	buf, err := json.Marshal(fields)
+	if err != nil {
+		return repo, nil, err
+	}
+	var merged org.Repo
+	if err := json.Unmarshal(buf, &merged); err != nil {
+		return repo, nil, err
+	}
+	merged.Previously = union(repo.Previously, more.Previously)
+	return merged, conflicts, nil
+}
+
+// repoFields returns the fields set in repo by JSON name.
+func repoFields(repo org.Repo) (map[string]interface{}, error) {
+	buf, err := json.Marshal(repo)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(buf, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// recordRepos records where each repo of the repos mapping of the file at
+// path is configured. The first definition wins.
+func (o *Org) recordRepos(path string, repos *yaml.Node) {
+	if repos == nil || repos.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(repos.Content); i += 2 {
+		if _, found := o.repos[repos.Content[i].Value]; !found {
+			o.repos[repos.Content[i].Value] = Source{File: path, Line: repos.Content[i].Line}
+		}
+	}
+}
+
+// resolveOverrides returns the file kept for each top-level team defined by
+// more than one of files, as set by overrides. Teams defined more than once
+// without an override are an error.
+func resolveOverrides(orgPath string, files []teamsFile, overrides Overrides) (map[string]string, error) {
+	definitions := map[string][]Source{}
+	for _, f := range files {
+		for _, name := range sortedKeys(f.teams) {
+			key, _ := mappingEntry(f.node, name)
+			definitions[name] = append(definitions[name], Source{File: f.path, Line: line(key)})
+		}
+	}
+
+	var problems []string
+	kept := map[string]string{}
+	for _, name := range sortedKeys(definitions) {
+		sources := definitions[name]
+		file, overridden := overrides[name]
+		if !overridden {
+			for _, source := range sources[1:] {
+				problems = append(problems, fmt.Sprintf("team %s is defined in both %s and %s", name, sources[0], source))
+			}
+			continue
+		}
+
+		path := filepath.Join(filepath.Dir(orgPath), file)
+		defined := false
+		for _, source := range sources {
+			defined = defined || source.File == path
+		}
+		switch {
+		case len(sources) == 1:
+			problems = append(problems, fmt.Sprintf("team %s is overridden but only defined in %s", name, sources[0]))
+		case !defined:
+			problems = append(problems, fmt.Sprintf("team %s is overridden by %s, which doesn't define it", name, path))
+		default:
+			kept[name] = path
+		}
+	}
+	for _, name := range sortedKeys(overrides) {
+		if _, found := definitions[name]; !found {
+			problems = append(problems, fmt.Sprintf("team %s is overridden but not defined", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	return kept, nil
+}
+
+// recordTeam records where the team at path, called name and defined at
+// source by node, and everything it holds are defined. It returns the teams,
+// the team itself or its children, whose name is already taken.
+func (o *Org) recordTeam(path, name string, source Source, node *yaml.Node) []string {
+	var dups []string
+	if existing, found := o.teamNames[strings.ToLower(name)]; found {
+		dups = append(dups, fmt.Sprintf("team %s is defined in both %s and %s", name, existing, source))
+	}
+	o.teamNames[strings.ToLower(name)] = source
+	o.teams[path] = source
+
+	for _, field := range []string{"maintainers", "members"} {
+		_, list := mappingEntry(node, field)
+		o.recordLogins(o.teamMembers, path+":", source.File, list)
+	}
+
+	_, repos := mappingEntry(node, "repos")
+	if repos != nil && repos.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(repos.Content); i += 2 {
+			key := path + ":" + repos.Content[i].Value
+			if _, found := o.teamRepos[key]; !found {
+				o.teamRepos[key] = Source{File: source.File, Line: repos.Content[i].Line}
+			}
+		}
+	}
+
+	_, children := mappingEntry(node, "teams")
+	if children != nil && children.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(children.Content); i += 2 {
+			key := children.Content[i]
+			dups = append(dups, o.recordTeam(path+"/"+key.Value, key.Value, Source{File: source.File, Line: key.Line}, children.Content[i+1])...)
+		}
+	}
+	return dups
+}
+
+// recordLogins records in sources where each login of list is listed, under
+// prefix followed by the normalized login. The first listing wins.
+func (o *Org) recordLogins(sources map[string]Source, prefix, file string, list *yaml.Node) {
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range list.Content {
+		key := prefix + github.NormLogin(item.Value)
+		if _, found := sources[key]; !found {
+			sources[key] = Source{File: file, Line: item.Line}
+		}
+	}
+}
+
+// mappingEntry returns the key and value nodes of key in mapping, or nil
+// nodes if mapping is not a mapping or lacks key.
+func mappingEntry(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func line(node *yaml.Node) int {
+	if node == nil {
+		return 0
+	}
+	return node.Line
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TeamSource returns where the team called name, as <parent>/<child> for
+// child teams, is defined.
+func (o *Org) TeamSource(name string) (Source, bool) {
+	s, found := o.teams[name]
+	return s, found
+}
+
+// MemberSource returns where login is listed as an org admin or member.
+func (o *Org) MemberSource(login string) (Source, bool) {
+	s, found := o.members[github.NormLogin(login)]
+	return s, found
+}
+
+// TeamMemberSource returns where login is listed as a maintainer or member of
+// the team called name, as <parent>/<child> for child teams.
+func (o *Org) TeamMemberSource(name, login string) (Source, bool) {
+	s, found := o.teamMembers[name+":"+github.NormLogin(login)]
+	return s, found
+}
+
+// RepoSource returns where the repo called name is first configured.
+func (o *Org) RepoSource(name string) (Source, bool) {
+	s, found := o.repos[name]
+	return s, found
+}
+
+// TeamRepoSource returns where the permission of the team called name, as
+// <parent>/<child> for child teams, on repo is set.
+func (o *Org) TeamRepoSource(name, repo string) (Source, bool) {
+	s, found := o.teamRepos[name+":"+repo]
+	return s, found
+}
+
+// Repository is the config of every org of a config directory, each org being
+// configured in <dir>/<org>/org.yaml.
+type Repository struct {
+	Dir  string
+	Orgs map[string]*Org
+}
+
+// Discover returns the names of the orgs configured in dir, sorted.
+func Discover(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", OrgFile))
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, path := range paths {
+		names = append(names, filepath.Base(filepath.Dir(path)))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load loads every org configured in dir. The OverridesFile of dir, if any,
+// may only list those orgs.
+func Load(dir string) (*Repository, error) {
+	names, err := Discover(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, OverridesFile)
+	if _, err := os.Stat(path); err == nil {
+		overrides, err := ReadOverrides(path)
+		if err != nil {
+			return nil, fmt.Errorf("error in %s: %v", path, err)
+		}
+		configured := map[string]bool{}
+		for _, name := range names {
+			configured[name] = true
+		}
+		for _, name := range sortedKeys(overrides.Orgs) {
+			if !configured[name] {
+				return nil, fmt.Errorf("error in %s: org %s is not configured", path, name)
+			}
+		}
+	}
+
+	return LoadOrgs(dir, names...)
+}
+
+// LoadOrgs loads the orgs of dir called names.
+func LoadOrgs(dir string, names ...string) (*Repository, error) {
+	r := &Repository{Dir: dir, Orgs: map[string]*Org{}}
+	for _, name := range names {
+		o, err := LoadOrg(filepath.Join(dir, name, OrgFile))
+		if err != nil {
+			return nil, err
+		}
+		r.Orgs[name] = o
+	}
+	return r, nil
+}
+
+// Names returns the names of the orgs of r, sorted.
+func (r *Repository) Names() []string {
+	names := make([]string, 0, len(r.Orgs))
+	for name := range r.Orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FullConfig returns the configs of the orgs of r, as peribolos expects them.
+func (r *Repository) FullConfig() org.FullConfig {
+	cfg := org.FullConfig{Orgs: map[string]org.Config{}}
+	for name, o := range r.Orgs {
+		cfg.Orgs[name] = o.Config
+	}
+	return cfg
+}
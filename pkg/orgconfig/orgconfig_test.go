@@ -0,0 +1,318 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/org/internal/testutil"
+)
+
+func TestLoad(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"kubernetes/org.yaml": `admins:
+- Alice
+members:
+- bob
+teams:
+  top:
+    members:
+    - bob
+`,
+		"kubernetes/sig-foo/teams.yaml": `teams:
+  foo:
+    maintainers:
+    - alice
+    repos:
+      website: write
+    teams:
+      foo-child:
+        members:
+        - carol
+`,
+		"kubernetes/sig-foo/sub/teams.yaml": "teams:\n  sub:\n    members:\n    - dave\n",
+		"other/org.yaml":                    "members:\n- erin\n",
+		"not-an-org/README.md":              "no org.yaml",
+	})
+
+	repo, err := Load(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := repo.Names(); !reflect.DeepEqual(names, []string{"kubernetes", "other"}) {
+		t.Errorf("unexpected orgs: %v", names)
+	}
+
+	k := repo.Orgs["kubernetes"]
+	orgFile := filepath.Join(root, "kubernetes/org.yaml")
+	fooFile := filepath.Join(root, "kubernetes/sig-foo/teams.yaml")
+	subFile := filepath.Join(root, "kubernetes/sig-foo/sub/teams.yaml")
+	if !reflect.DeepEqual(k.Files, []string{orgFile, subFile, fooFile}) {
+		t.Errorf("unexpected files: %v", k.Files)
+	}
+	if len(k.Config.Teams) != 3 {
+		t.Errorf("unexpected teams: %v", k.Config.Teams)
+	}
+
+	sources := []struct {
+		desc     string
+		lookup   func() (Source, bool)
+		expected string
+	}{
+		{"team in org.yaml", func() (Source, bool) { return k.TeamSource("top") }, orgFile + ":6"},
+		{"team in teams.yaml", func() (Source, bool) { return k.TeamSource("foo") }, fooFile + ":2"},
+		{"child team", func() (Source, bool) { return k.TeamSource("foo/foo-child") }, fooFile + ":8"},
+		{"team in a nested teams.yaml", func() (Source, bool) { return k.TeamSource("sub") }, subFile + ":2"},
+		{"unknown team", func() (Source, bool) { return k.TeamSource("foo-child") }, ""},
+		{"org admin", func() (Source, bool) { return k.MemberSource("@alice") }, orgFile + ":2"},
+		{"org member", func() (Source, bool) { return k.MemberSource("bob") }, orgFile + ":4"},
+		{"not an org member", func() (Source, bool) { return k.MemberSource("carol") }, ""},
+		{"team maintainer", func() (Source, bool) { return k.TeamMemberSource("foo", "Alice") }, fooFile + ":4"},
+		{"child team member", func() (Source, bool) { return k.TeamMemberSource("foo/foo-child", "carol") }, fooFile + ":10"},
+		{"team member in org.yaml", func() (Source, bool) { return k.TeamMemberSource("top", "bob") }, orgFile + ":8"},
+		{"not a team member", func() (Source, bool) { return k.TeamMemberSource("top", "alice") }, ""},
+		{"team repo permission", func() (Source, bool) { return k.TeamRepoSource("foo", "website") }, fooFile + ":6"},
+		{"no repo permission", func() (Source, bool) { return k.TeamRepoSource("top", "website") }, ""},
+	}
+	for _, s := range sources {
+		source, found := s.lookup()
+		if found != (s.expected != "") || (found && source.String() != s.expected) {
+			t.Errorf("%s: unexpected source %q, found: %t, expected %q", s.desc, source, found, s.expected)
+		}
+	}
+
+	full := repo.FullConfig()
+	if len(full.Orgs) != 2 || len(full.Orgs["other"].Members) != 1 {
+		t.Errorf("unexpected full config: %v", full)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	files := map[string]string{
+		"kubernetes/org.yaml":           "teams:\n  dup:\n    description: org\n",
+		"kubernetes/sig-foo/teams.yaml": "teams:\n  dup:\n    description: sig-foo\n",
+		"other/org.yaml":                "name: Other\n",
+		OverridesFile:                   "orgs:\n  kubernetes:\n    teams:\n      dup: sig-foo/teams.yaml\n",
+	}
+	root := testutil.WriteFiles(t, files)
+
+	repo, err := Load(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if description := repo.Orgs["kubernetes"].Config.Teams["dup"].Description; description == nil || *description != "sig-foo" {
+		t.Errorf("kept the wrong definition of dup, description %v", description)
+	}
+
+	// orgs loaded on their own use the overrides too
+	o, err := LoadOrg(filepath.Join(root, "kubernetes", OrgFile))
+	if err != nil {
+		t.Fatalf("unexpected error loading the org: %v", err)
+	}
+	if source, _ := o.TeamSource("dup"); source.File != filepath.Join(root, "kubernetes/sig-foo/teams.yaml") {
+		t.Errorf("unexpected source of dup: %s", source)
+	}
+
+	files[OverridesFile] = "orgs:\n  kubernetes-retired:\n    teams:\n      dup: sig-foo/teams.yaml\n"
+	root = testutil.WriteFiles(t, files)
+	expected := "error in " + filepath.Join(root, OverridesFile) + ": org kubernetes-retired is not configured"
+	if _, err := Load(root); err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestLoadOrgErrors(t *testing.T) {
+	cases := []struct {
+		desc     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			desc:     "unknown field in org.yaml",
+			files:    map[string]string{"org.yaml": "member:\n- bob\n"},
+			expected: `unknown field "member"`,
+		},
+		{
+			desc:     "unknown field in a teams.yaml",
+			files:    map[string]string{"org.yaml": "name: Org\n", "sig-foo/teams.yaml": "teams:\n  foo:\n    member:\n    - bob\n"},
+			expected: "sig-foo/teams.yaml: unmarshal",
+		},
+		{
+			desc: "team defined twice",
+			files: map[string]string{
+				"org.yaml":           "teams:\n  foo: {}\n  bar: {}\n",
+				"sig-foo/teams.yaml": "teams:\n  foo: {}\n  bar: {}\n",
+			},
+			expected: "team bar is defined in both ORG/org.yaml:3 and ORG/sig-foo/teams.yaml:3",
+		},
+		{
+			desc:     "missing org.yaml",
+			files:    map[string]string{"sig-foo/teams.yaml": "teams: {}\n"},
+			expected: "read:",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			root := testutil.WriteFiles(t, tc.files)
+			_, err := LoadOrg(filepath.Join(root, OrgFile))
+			expected := strings.ReplaceAll(tc.expected, "ORG", root)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected an error containing %q, got %v", expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadOrgFragments(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"org.yaml": `admins:
+- alice
+members:
+- bob
+repos:
+  website:
+    description: The website
+`,
+		"members.d/sig-foo.yaml": "members:\n- Bob\n- carol\n",
+		"members.d/sig-bar.yaml": "admins:\n- dave\nmembers:\n- erin\n",
+		"members.d/README.md":    "not a fragment",
+		"sig-foo/repos.yaml": `repos:
+  website:
+    has_wiki: false
+    previously:
+    - www
+  foo:
+    private: true
+`,
+		"sig-bar/repos.yaml": `repos:
+  website:
+    description: The website
+    previously:
+    - site
+    - www
+`,
+	})
+
+	o, err := LoadOrg(filepath.Join(root, OrgFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []string{"alice", "dave"}; !reflect.DeepEqual(o.Config.Admins, expected) {
+		t.Errorf("unexpected admins %v, expected %v", o.Config.Admins, expected)
+	}
+	if expected := []string{"bob", "erin", "carol"}; !reflect.DeepEqual(o.Config.Members, expected) {
+		t.Errorf("unexpected members %v, expected %v", o.Config.Members, expected)
+	}
+
+	website := o.Config.Repos["website"]
+	if website.Description == nil || *website.Description != "The website" || website.HasWiki == nil || *website.HasWiki {
+		t.Errorf("unexpected website repo: %+v", website)
+	}
+	if expected := []string{"site", "www"}; !reflect.DeepEqual(website.Previously, expected) {
+		t.Errorf("unexpected previous names %v, expected %v", website.Previously, expected)
+	}
+	if foo := o.Config.Repos["foo"]; foo.Private == nil || !*foo.Private {
+		t.Errorf("unexpected foo repo: %+v", foo)
+	}
+
+	sources := []struct {
+		desc     string
+		lookup   func() (Source, bool)
+		expected string
+	}{
+		{"member of org.yaml and a fragment", func() (Source, bool) { return o.MemberSource("bob") }, "org.yaml:4"},
+		{"member of a fragment", func() (Source, bool) { return o.MemberSource("carol") }, "members.d/sig-foo.yaml:3"},
+		{"admin of a fragment", func() (Source, bool) { return o.MemberSource("dave") }, "members.d/sig-bar.yaml:2"},
+		{"repo of org.yaml and fragments", func() (Source, bool) { return o.RepoSource("website") }, "org.yaml:6"},
+		{"repo of a fragment", func() (Source, bool) { return o.RepoSource("foo") }, "sig-foo/repos.yaml:6"},
+	}
+	for _, s := range sources {
+		source, found := s.lookup()
+		if got := strings.TrimPrefix(source.String(), root+"/"); !found || got != s.expected {
+			t.Errorf("%s: unexpected source %q, found: %t, expected %q", s.desc, got, found, s.expected)
+		}
+	}
+}
+
+func TestLoadOrgFragmentErrors(t *testing.T) {
+	cases := []struct {
+		desc     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			desc:     "teams in a members fragment",
+			files:    map[string]string{"org.yaml": "name: Org\n", "members.d/foo.yaml": "teams:\n  foo: {}\n"},
+			expected: `members.d/foo.yaml: unmarshal: error unmarshaling JSON: while decoding JSON: json: unknown field "teams"`,
+		},
+		{
+			desc:     "members in a repos fragment",
+			files:    map[string]string{"org.yaml": "name: Org\n", "sig-foo/repos.yaml": "members:\n- bob\n"},
+			expected: `sig-foo/repos.yaml: unmarshal: error unmarshaling JSON: while decoding JSON: json: unknown field "members"`,
+		},
+		{
+			desc: "org admin listed as a member in a fragment",
+			files: map[string]string{
+				"org.yaml":           "admins:\n- alice\nmembers:\n- bob\n",
+				"members.d/foo.yaml": "members:\n- carol\n- Alice\n",
+			},
+			expected: "Alice is one of the admins in ORG/org.yaml:2 and of the members in ORG/members.d/foo.yaml:3",
+		},
+		{
+			desc: "org member listed as an admin in a fragment",
+			files: map[string]string{
+				"org.yaml":           "admins:\n- alice\nmembers:\n- bob\n",
+				"members.d/foo.yaml": "admins:\n- bob\n",
+			},
+			expected: "bob is one of the members in ORG/org.yaml:4 and of the admins in ORG/members.d/foo.yaml:2",
+		},
+		{
+			desc: "admin and member in different fragments",
+			files: map[string]string{
+				"org.yaml":           "admins:\n- alice\n",
+				"members.d/bar.yaml": "members:\n- carol\n",
+				"members.d/foo.yaml": "admins:\n- carol\n",
+			},
+			expected: "carol is one of the members in ORG/members.d/bar.yaml:2 and of the admins in ORG/members.d/foo.yaml:2",
+		},
+		{
+			desc: "conflicting repo fields",
+			files: map[string]string{
+				"org.yaml":           "repos:\n  foo:\n    description: Foo\n    private: true\n",
+				"sig-foo/repos.yaml": "repos:\n  foo:\n    description: Bar\n    private: false\n",
+			},
+			expected: "description of repo foo is set to different values in ORG/org.yaml:2 and ORG/sig-foo/repos.yaml:2\n" +
+				"private of repo foo is set to different values in ORG/org.yaml:2 and ORG/sig-foo/repos.yaml:2",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			root := testutil.WriteFiles(t, tc.files)
+			_, err := LoadOrg(filepath.Join(root, OrgFile))
+			expected := strings.ReplaceAll(tc.expected, "ORG", root)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected an error containing %q, got %v", expected, err)
+			}
+		})
+	}
+}